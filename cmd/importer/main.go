@@ -0,0 +1,138 @@
+// Command importer loads airport reference data from OurAirports CSV
+// exports into MongoDB. Re-running it with the same files changes nothing.
+//
+//	go run ./cmd/importer -airports airports.csv -runways runways.csv -dry-run
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+	_ "time/tzdata" // validate zones from the timezone file
+
+	"github.com/onoja123/travel-companion-backend/internal/config"
+	"github.com/onoja123/travel-companion-backend/internal/database"
+	"github.com/onoja123/travel-companion-backend/internal/importer"
+)
+
+func main() {
+	airportsPath := flag.String("airports", "", "OurAirports airports.csv (required)")
+	runwaysPath := flag.String("runways", "", "OurAirports runways.csv; runways are left untouched when empty")
+	countriesPath := flag.String("countries", "", "OurAirports countries.csv, for country names")
+	trafficPath := flag.String("traffic", "", "iata_code,passengers CSV of annual traffic, for search ranking")
+	timezonesPath := flag.String("timezones", "", "iata_code,timezone CSV or OpenFlights airports.dat")
+	allowNoTimezone := flag.Bool("allow-missing-timezones", false, "import airports whose timezone is unknown instead of failing")
+	types := flag.String("types", "large_airport,medium_airport", "comma-separated airport types to import")
+	scheduled := flag.Bool("scheduled", true, "only import airports with scheduled service")
+	dryRun := flag.Bool("dry-run", false, "report changes without writing them")
+	verbose := flag.Bool("v", false, "list every inserted and updated airport")
+	flag.Parse()
+
+	if *airportsPath == "" {
+		flag.Usage()
+		log.Fatal("-airports is required")
+	}
+
+	filter := importer.Filter{ScheduledOnly: *scheduled}
+	for _, t := range strings.Split(*types, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			filter.Types = append(filter.Types, t)
+		}
+	}
+
+	records, err := importer.ReadAirports(*airportsPath, filter)
+	if err != nil {
+		log.Fatal("Failed to read airports:", err)
+	}
+	log.Printf("Read %d airports from %s", len(records), *airportsPath)
+
+	imp := &importer.Importer{DryRun: *dryRun, AllowNoTimezone: *allowNoTimezone}
+	if *runwaysPath != "" {
+		if imp.Runways, err = importer.ReadRunways(*runwaysPath); err != nil {
+			log.Fatal("Failed to read runways:", err)
+		}
+	}
+	if *countriesPath != "" {
+		if imp.Countries, err = importer.ReadCountries(*countriesPath); err != nil {
+			log.Fatal("Failed to read countries:", err)
+		}
+	}
+	if *trafficPath != "" {
+		if imp.Traffic, err = importer.ReadTraffic(*trafficPath); err != nil {
+			log.Fatal("Failed to read traffic:", err)
+		}
+	}
+
+	zones, err := importer.LoadTimezones(*timezonesPath)
+	if err != nil {
+		log.Fatal("Failed to read timezones:", err)
+	}
+
+	cfg := config.Load()
+	db, err := database.NewMongoDB(cfg.MongoDB.URI, cfg.MongoDB.Database)
+	if err != nil {
+		log.Fatal("Failed to connect to MongoDB:", err)
+	}
+	defer db.Close()
+	imp.MongoDB = db
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if !*dryRun {
+		if err := db.EnsureIndexes(ctx); err != nil {
+			log.Fatal("Failed to ensure indexes:", err)
+		}
+	}
+
+	report, err := imp.Run(ctx, records, zones)
+	if err != nil {
+		if errors.Is(err, importer.ErrNoTimezone) {
+			printReport(report, *dryRun, *verbose)
+			log.Fatal("Import failed: pass -timezones with zones for the airports above, or -allow-missing-timezones")
+		}
+		log.Fatal("Import failed:", err)
+	}
+
+	printReport(report, *dryRun, *verbose)
+}
+
+func printReport(report *importer.Report, dryRun, verbose bool) {
+	prefix := ""
+	if dryRun {
+		prefix = "[dry run] "
+	}
+
+	fmt.Printf("%sinserted %d, updated %d, unchanged %d\n",
+		prefix, len(report.Inserted), len(report.Updated), report.Unchanged)
+
+	if verbose {
+		for _, code := range report.Inserted {
+			fmt.Printf("  + %s\n", code)
+		}
+		for _, change := range report.Updated {
+			fmt.Printf("  ~ %s: %s\n", change.Code, strings.Join(change.Fields, ", "))
+		}
+	}
+
+	var sources []string
+	for _, source := range slices.Sorted(maps.Keys(report.ZoneSource)) {
+		sources = append(sources, fmt.Sprintf("%s %d", source, report.ZoneSource[source]))
+	}
+	if len(sources) > 0 {
+		fmt.Printf("timezones: %s\n", strings.Join(sources, ", "))
+	}
+	if len(report.NoTimezone) > 0 {
+		fmt.Printf("no timezone for %d airports (pass -timezones): %s\n",
+			len(report.NoTimezone), strings.Join(report.NoTimezone, " "))
+	}
+	if len(report.Duplicates) > 0 {
+		fmt.Printf("skipped duplicate IATA codes: %s\n", strings.Join(report.Duplicates, " "))
+	}
+}
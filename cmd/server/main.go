@@ -3,19 +3,27 @@ package main
 import (
 	"context"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // airport zones for localized notification times
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/onoja123/travel-companion-backend/internal/config"
 	handlers "github.com/onoja123/travel-companion-backend/internal/controllers"
 	"github.com/onoja123/travel-companion-backend/internal/database"
+	"github.com/onoja123/travel-companion-backend/internal/i18n"
 	"github.com/onoja123/travel-companion-backend/internal/middleware"
 	"github.com/onoja123/travel-companion-backend/internal/routes"
 	"github.com/onoja123/travel-companion-backend/internal/services"
+	"github.com/onoja123/travel-companion-backend/internal/terminal"
+	"github.com/onoja123/travel-companion-backend/internal/waittime"
+	"github.com/onoja123/travel-companion-backend/internal/weather"
+	"github.com/onoja123/travel-companion-backend/internal/websocket"
+	"github.com/onoja123/travel-companion-backend/pkg/fcm"
 )
 
 func main() {
@@ -36,6 +44,12 @@ func main() {
 
 	log.Println("✅ Connected to MongoDB")
 
+	indexCtx, indexCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	if err := db.EnsureIndexes(indexCtx); err != nil {
+		log.Printf("Warning: Failed to ensure MongoDB indexes: %v", err)
+	}
+	indexCancel()
+
 	// Initialize Redis
 	redisClient, err := database.NewRedisClient(cfg.Redis.URL, cfg.Redis.Password)
 	if err != nil {
@@ -47,16 +61,72 @@ func main() {
 	// Initialize all services in one place
 	// Initialize all controllers
 	aviationService := services.NewAviationService(cfg)
-	notificationService := services.NewNotificationService(db, nil) // FCMService can be added
-	flightService := services.NewFlightService(db, redisClient, aviationService, notificationService)
-	locationService := services.NewLocationService(db, redisClient)
+	terminals, err := terminal.LoadDir(cfg.Location.TerminalGraphDir)
+	if err != nil {
+		log.Fatal("Failed to load terminal graphs:", err)
+	}
+	waitSources, err := waittime.NewSources(cfg)
+	if err != nil {
+		log.Fatal("Failed to configure wait time feeds:", err)
+	}
+	weatherSource, err := weather.NewSource(cfg)
+	if err != nil {
+		log.Fatal("Failed to configure weather source:", err)
+	}
+	holidays, err := services.LoadHolidays(cfg.Location.HolidayCalendar)
+	if err != nil {
+		log.Fatal("Failed to load holiday calendar:", err)
+	}
+	templates, err := i18n.NewRenderer("en")
+	if err != nil {
+		log.Fatal("Failed to load notification templates:", err)
+	}
+
+	// Push notifications are optional in development
+	var fcmService *fcm.FCMService
+	if cfg.Firebase.Backend == "fake" {
+		fcmService = fcm.NewFCMServiceWithBackend(fcm.NewFakeBackend())
+		log.Println("Using in-memory FCM backend")
+	} else if fcmService, err = fcm.NewFCMService(cfg.Firebase.CredentialsPath); err != nil {
+		log.Printf("Warning: FCM disabled: %v", err)
+	}
+
+	// Realtime hub, shared across replicas through Redis
+	hub := websocket.NewHub(cfg)
+	backplane := websocket.NewBackplane(hub, redisClient)
+
+	deviceService := services.NewDeviceService(db)
+	migrateCtx, migrateCancel := context.WithTimeout(context.Background(), time.Minute)
+	if migrated, err := deviceService.MigrateLegacyTokens(migrateCtx); err != nil {
+		log.Printf("Warning: Failed to migrate legacy FCM tokens: %v", err)
+	} else if migrated > 0 {
+		log.Printf("Migrated %d legacy FCM tokens to devices", migrated)
+	}
+	migrateCancel()
 
-	airportController := handlers.NewAirportController(db, locationService)
+	notificationService := services.NewNotificationService(db, fcmService, deviceService, templates)
+	if cfg.SMTP.Host != "" {
+		notificationService.RegisterChannel(i18n.ChannelEmail, services.NewSMTPSender(cfg.SMTP))
+	}
+	flightService := services.NewFlightService(db, redisClient, aviationService, notificationService, backplane)
+	locationService := services.NewLocationService(db, redisClient, terminals, backplane, notificationService)
+	locationService.HistoryRetention = cfg.Location.HistoryRetention
+	locationService.Holidays = holidays
+	waitTimeIngestor := services.NewWaitTimeIngestor(db, redisClient, waitSources, cfg.WaitTimes.Interval)
+	airportService := services.NewAirportService(db)
+	amenityService := services.NewAmenityService(db, terminals)
+	weatherService := services.NewWeatherService(db, redisClient, weatherSource, cfg.Weather.CacheTTL)
+	flightService.Weather = weatherService
+
+	airportController := handlers.NewAirportController(db, airportService, amenityService, locationService, weatherService)
 	authController := handlers.NewAuthHandler(db, cfg)
+	deviceController := handlers.NewDeviceHandler(deviceService)
 	flightController := handlers.NewFlightHandler(flightService)
 	locationController := handlers.NewLocationHandler(locationService)
 	notificationController := handlers.NewNotificationHandler(notificationService)
 
+	wsHandler := websocket.NewHandler(hub, cfg, redisClient, flightService, locationService)
+
 	// Setup Gin router
 	if cfg.Server.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -73,15 +143,31 @@ func main() {
 		c.JSON(200, gin.H{"status": "healthy"})
 	})
 
-	// Register all API routes in a separate function for cleaner code
-
 	// Register all API routes
-	routes.RegisterRoutes(router, airportController, authController, flightController, locationController, notificationController)
+	routes.RegisterRoutes(router, middleware.AuthMiddleware(cfg), middleware.AdminMiddleware(db), airportController, authController, deviceController, flightController, locationController, notificationController, wsHandler)
+
+	// Background services stop when the server shuts down
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	go hub.Run(bgCtx)
+	go backplane.Run(bgCtx)
+	go flightService.StartPollingService(bgCtx)
+	go notificationService.StartReminderService(bgCtx)
+	go notificationService.StartDigestService(bgCtx)
+	go locationService.StartHistoryService(bgCtx)
+	go locationService.StartForecastService(bgCtx)
+	go waitTimeIngestor.Start(bgCtx)
 
 	// Start server
+	srv := &http.Server{
+		Addr:    ":" + cfg.Server.Port,
+		Handler: router,
+	}
+
 	go func() {
 		log.Printf("🚀 Server starting on port %s", cfg.Server.Port)
-		if err := router.Run(":" + cfg.Server.Port); err != nil {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatal("Failed to start server:", err)
 		}
 	}()
@@ -92,9 +178,14 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down server...")
-	_, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	stopBackground() // also closes WebSocket connections, which Shutdown does not track
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer shutdownCancel()
 
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error shutting down HTTP server: %v", err)
+	}
+
 	if err := db.Close(); err != nil {
 		log.Fatal("Error disconnecting from MongoDB:", err)
 	}
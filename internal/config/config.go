@@ -3,18 +3,25 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Server   ServerConfig
-	MongoDB  MongoDBConfig
-	Redis    RedisConfig
-	JWT      JWTConfig
-	Firebase FirebaseConfig
-	Aviation AviationConfig
+	Server    ServerConfig
+	MongoDB   MongoDBConfig
+	Redis     RedisConfig
+	JWT       JWTConfig
+	Firebase  FirebaseConfig
+	Aviation  AviationConfig
+	WS        WebSocketConfig
+	Location  LocationConfig
+	WaitTimes WaitTimeConfig
+	Weather   WeatherConfig
+	SMTP      SMTPConfig
 }
 
 type ServerConfig struct {
@@ -39,6 +46,7 @@ type JWTConfig struct {
 
 type FirebaseConfig struct {
 	CredentialsPath string
+	Backend         string // "firebase" or "fake" (in-memory, for local development)
 }
 
 type AviationConfig struct {
@@ -49,12 +57,51 @@ type AviationConfig struct {
 	AmadeusClientSecret string
 }
 
+type WebSocketConfig struct {
+	AllowedOrigins []string      // cross-origin browser origins allowed to connect
+	TicketTTL      time.Duration // lifetime of single-use connection tickets
+	SendQueueSize  int           // outbound messages buffered per connection
+	DropPolicy     string        // full queue: drop_oldest, drop_newest or disconnect
+}
+
+type LocationConfig struct {
+	TerminalGraphDir string        // per-airport terminal graphs (.json/.geojson)
+	HistoryRetention time.Duration // longest opt-in location history is kept
+	HolidayCalendar  string        // holidays for security wait forecasts (.json)
+}
+
+type WaitTimeConfig struct {
+	TSAURL   string        // TSA-style wait time API; empty disables it
+	Feeds    []string      // airport feeds as CODE=URL (CSV or JSON)
+	Interval time.Duration // how often feeds are fetched
+}
+
+type WeatherConfig struct {
+	Source   string        // "aviationweather" or "file"
+	URL      string        // aviationweather.gov-style data API
+	Dir      string        // <ICAO>.metar/.taf files for the file source
+	CacheTTL time.Duration // how long fetched reports are reused
+}
+
+type SMTPConfig struct {
+	Host     string // SMTP relay for email digests; empty disables email
+	Port     string
+	Username string
+	Password string
+	From     string // sender address
+}
+
 func Load() *Config {
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using environment variables")
 	}
 
 	expiryDuration, _ := time.ParseDuration(getEnv("JWT_EXPIRY", "24h"))
+	ticketTTL, _ := time.ParseDuration(getEnv("WS_TICKET_TTL", "30s"))
+	sendQueueSize, _ := strconv.Atoi(getEnv("WS_SEND_QUEUE_SIZE", "256"))
+	historyRetention, _ := time.ParseDuration(getEnv("LOCATION_HISTORY_RETENTION", "720h"))
+	waitTimeInterval, _ := time.ParseDuration(getEnv("WAIT_TIME_INTERVAL", "10m"))
+	weatherCacheTTL, _ := time.ParseDuration(getEnv("WEATHER_CACHE_TTL", "10m"))
 
 	return &Config{
 		Server: ServerConfig{
@@ -75,6 +122,7 @@ func Load() *Config {
 		},
 		Firebase: FirebaseConfig{
 			CredentialsPath: getEnv("FIREBASE_CREDENTIALS_PATH", "./firebase-credentials.json"),
+			Backend:         getEnv("FCM_BACKEND", "firebase"),
 		},
 		Aviation: AviationConfig{
 			Provider:            getEnv("AVIATION_API_PROVIDER", "aviationstack"),
@@ -83,6 +131,35 @@ func Load() *Config {
 			AmadeusClientID:     getEnv("AMADEUS_CLIENT_ID", ""),
 			AmadeusClientSecret: getEnv("AMADEUS_CLIENT_SECRET", ""),
 		},
+		WS: WebSocketConfig{
+			AllowedOrigins: splitList(getEnv("WS_ALLOWED_ORIGINS", "")),
+			TicketTTL:      ticketTTL,
+			SendQueueSize:  sendQueueSize,
+			DropPolicy:     getEnv("WS_DROP_POLICY", "drop_oldest"),
+		},
+		Location: LocationConfig{
+			TerminalGraphDir: getEnv("TERMINAL_GRAPH_DIR", "./data/terminals"),
+			HistoryRetention: historyRetention,
+			HolidayCalendar:  getEnv("HOLIDAY_CALENDAR", "./data/holidays.json"),
+		},
+		WaitTimes: WaitTimeConfig{
+			TSAURL:   getEnv("TSA_API_URL", ""),
+			Feeds:    splitList(getEnv("WAIT_TIME_FEEDS", "")),
+			Interval: waitTimeInterval,
+		},
+		Weather: WeatherConfig{
+			Source:   getEnv("WEATHER_SOURCE", "aviationweather"),
+			URL:      getEnv("WEATHER_API_URL", "https://aviationweather.gov/api/data"),
+			Dir:      getEnv("WEATHER_DIR", "./data/weather"),
+			CacheTTL: weatherCacheTTL,
+		},
+		SMTP: SMTPConfig{
+			Host:     getEnv("SMTP_HOST", ""),
+			Port:     getEnv("SMTP_PORT", "587"),
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			From:     getEnv("SMTP_FROM", ""),
+		},
 	}
 }
 
@@ -92,3 +169,13 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
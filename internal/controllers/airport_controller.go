@@ -2,6 +2,9 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -9,21 +12,111 @@ import (
 	"github.com/onoja123/travel-companion-backend/internal/models"
 	"github.com/onoja123/travel-companion-backend/internal/services"
 	"github.com/onoja123/travel-companion-backend/internal/utils"
+	"github.com/onoja123/travel-companion-backend/internal/weather"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type AirportController struct {
 	MongoDB         *database.MongoDB
+	AirportService  *services.AirportService
+	AmenityService  *services.AmenityService
 	LocationService *services.LocationService
+	WeatherService  *services.WeatherService
 }
 
-func NewAirportController(db *database.MongoDB, locationService *services.LocationService) *AirportController {
+func NewAirportController(db *database.MongoDB, airportService *services.AirportService, amenityService *services.AmenityService, locationService *services.LocationService, weatherService *services.WeatherService) *AirportController {
 	return &AirportController{
 		MongoDB:         db,
+		AirportService:  airportService,
+		AmenityService:  amenityService,
 		LocationService: locationService,
+		WeatherService:  weatherService,
 	}
 }
 
+// SearchAirports godoc
+// @Summary Search airports
+// @Description Type-ahead search by IATA or ICAO code, name, city or country, tolerant of typos and ranked by passenger traffic
+// @Tags airports
+// @Produce json
+// @Param q query string true "Search text (at least 2 characters)"
+// @Param limit query int false "Maximum results (default 10, max 25)"
+// @Success 200 {array} models.AirportSummary
+// @Router /api/airports/search [get]
+func (h *AirportController) SearchAirports(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if len([]rune(query)) < 2 {
+		utils.ErrorResponse(c, 400, "Search text must be at least 2 characters")
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 25 {
+		utils.ErrorResponse(c, 400, "Invalid limit")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	airports, err := h.AirportService.Search(ctx, query, limit)
+	if err != nil {
+		utils.ErrorResponse(c, 500, "Failed to search airports")
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Airports retrieved", airports)
+}
+
+// NearbyAirports godoc
+// @Summary Find nearby airports
+// @Description List airports within a radius of a point, nearest first
+// @Tags airports
+// @Produce json
+// @Param lat query number true "Latitude"
+// @Param lon query number true "Longitude"
+// @Param radius query number false "Radius in km (default 100, max 500)"
+// @Param limit query int false "Maximum results (default 20, max 50)"
+// @Success 200 {array} models.NearbyAirport
+// @Router /api/airports/nearby [get]
+func (h *AirportController) NearbyAirports(c *gin.Context) {
+	lat, err := strconv.ParseFloat(c.Query("lat"), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		utils.ErrorResponse(c, 400, "Invalid latitude")
+		return
+	}
+
+	lon, err := strconv.ParseFloat(c.Query("lon"), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		utils.ErrorResponse(c, 400, "Invalid longitude")
+		return
+	}
+
+	radius, err := strconv.ParseFloat(c.DefaultQuery("radius", "100"), 64)
+	if err != nil || radius <= 0 || radius > 500 {
+		utils.ErrorResponse(c, 400, "Invalid radius")
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 50 {
+		utils.ErrorResponse(c, 400, "Invalid limit")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	airports, err := h.AirportService.Nearby(ctx, lat, lon, radius, limit)
+	if err != nil {
+		utils.ErrorResponse(c, 500, "Failed to find nearby airports")
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Nearby airports retrieved", airports)
+}
+
 // GetAirport godoc
 // @Summary Get airport information
 // @Description Get details about a specific airport
@@ -80,3 +173,353 @@ func (h *AirportController) GetSecurityWaitTime(c *gin.Context) {
 
 	utils.SuccessResponse(c, 200, "Security wait time retrieved", waitTime)
 }
+
+// ForecastSecurityWait godoc
+// @Summary Forecast security wait time
+// @Description Predict the security wait at an airport for a given time from its hour-of-week history, adjusted for holidays
+// @Tags airports
+// @Produce json
+// @Param code path string true "Airport Code (IATA)"
+// @Param at query string false "Arrival time (RFC 3339, default now)"
+// @Success 200 {object} models.SecurityWaitForecast
+// @Router /api/airports/{code}/security-wait/forecast [get]
+func (h *AirportController) ForecastSecurityWait(c *gin.Context) {
+	code := c.Param("code")
+
+	if !utils.IsValidAirportCode(code) {
+		utils.ErrorResponse(c, 400, "Invalid airport code")
+		return
+	}
+
+	at := time.Now()
+	if v := c.Query("at"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			utils.ErrorResponse(c, 400, "Invalid at time")
+			return
+		}
+		at = t
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	forecast, err := h.LocationService.ForecastSecurityWait(ctx, code, at)
+	if err != nil {
+		utils.ErrorResponse(c, 404, "Security wait forecast not available")
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Security wait forecast retrieved", forecast)
+}
+
+// GetWeather godoc
+// @Summary Get airport weather
+// @Description Get the airport's latest METAR and TAF, parsed into wind, visibility, ceiling and weather, with a weather delay risk for now
+// @Tags airports
+// @Produce json
+// @Param code path string true "Airport Code (IATA)"
+// @Success 200 {object} models.AirportWeather
+// @Router /api/airports/{code}/weather [get]
+func (h *AirportController) GetWeather(c *gin.Context) {
+	code := c.Param("code")
+
+	if !utils.IsValidAirportCode(code) {
+		utils.ErrorResponse(c, 400, "Invalid airport code")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	report, err := h.WeatherService.GetAirportWeather(ctx, code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrAirportNotFound):
+			utils.ErrorResponse(c, 404, "Airport not found")
+		case errors.Is(err, services.ErrNoWeatherStation), errors.Is(err, weather.ErrNoReport):
+			utils.ErrorResponse(c, 404, "Weather not available")
+		default:
+			utils.ErrorResponse(c, 500, "Failed to fetch weather")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Weather retrieved", report)
+}
+
+// GetTerminals godoc
+// @Summary List airport terminals
+// @Description List an airport's terminals and their gates
+// @Tags airports
+// @Produce json
+// @Param code path string true "Airport Code (IATA)"
+// @Success 200 {array} models.Terminal
+// @Router /api/airports/{code}/terminals [get]
+func (h *AirportController) GetTerminals(c *gin.Context) {
+	code := c.Param("code")
+
+	if !utils.IsValidAirportCode(code) {
+		utils.ErrorResponse(c, 400, "Invalid airport code")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	terminals, err := h.AmenityService.GetTerminals(ctx, code)
+	if err != nil {
+		amenityError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Terminals retrieved", terminals)
+}
+
+// PutTerminal godoc
+// @Summary Create or replace a terminal
+// @Description Create a terminal, or replace it and its gates (admin only)
+// @Tags airports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code path string true "Airport Code (IATA)"
+// @Param terminal path string true "Terminal code"
+// @Param request body models.Terminal true "Terminal and gates"
+// @Success 200 {object} models.Terminal
+// @Router /api/airports/{code}/terminals/{terminal} [put]
+func (h *AirportController) PutTerminal(c *gin.Context) {
+	code := c.Param("code")
+
+	if !utils.IsValidAirportCode(code) {
+		utils.ErrorResponse(c, 400, "Invalid airport code")
+		return
+	}
+
+	var req models.Terminal
+	req.Code = c.Param("terminal")
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+	if req.Code != c.Param("terminal") {
+		utils.ErrorResponse(c, 400, "Terminal code does not match the URL")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	terminal, err := h.AmenityService.PutTerminal(ctx, code, req)
+	if err != nil {
+		amenityError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Terminal saved", terminal)
+}
+
+// DeleteTerminal godoc
+// @Summary Delete a terminal
+// @Description Delete a terminal and its gates (admin only)
+// @Tags airports
+// @Produce json
+// @Security BearerAuth
+// @Param code path string true "Airport Code (IATA)"
+// @Param terminal path string true "Terminal code"
+// @Success 200 {object} utils.Response
+// @Router /api/airports/{code}/terminals/{terminal} [delete]
+func (h *AirportController) DeleteTerminal(c *gin.Context) {
+	code := c.Param("code")
+
+	if !utils.IsValidAirportCode(code) {
+		utils.ErrorResponse(c, 400, "Invalid airport code")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.AmenityService.DeleteTerminal(ctx, code, c.Param("terminal")); err != nil {
+		amenityError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Terminal deleted", nil)
+}
+
+// GetAmenities godoc
+// @Summary List airport amenities
+// @Description List food, shops, lounges and other amenities. With near_gate, only airside amenities close to that gate (e.g. the gate from a flight's status) are listed, nearest first
+// @Tags airports
+// @Produce json
+// @Param code path string true "Airport Code (IATA)"
+// @Param near_gate query string false "Gate label, e.g. B32"
+// @Param terminal query string false "Terminal code"
+// @Param category query string false "food, drink, coffee, shop, lounge, restroom, pharmacy, charging or other"
+// @Param open_now query bool false "Only amenities open now"
+// @Success 200 {array} models.AmenityResult
+// @Router /api/airports/{code}/amenities [get]
+func (h *AirportController) GetAmenities(c *gin.Context) {
+	code := c.Param("code")
+
+	if !utils.IsValidAirportCode(code) {
+		utils.ErrorResponse(c, 400, "Invalid airport code")
+		return
+	}
+
+	filter := services.AmenityFilter{
+		NearGate: strings.TrimSpace(c.Query("near_gate")),
+		Terminal: c.Query("terminal"),
+		Category: c.Query("category"),
+	}
+	if v := c.Query("open_now"); v != "" {
+		openNow, err := strconv.ParseBool(v)
+		if err != nil {
+			utils.ErrorResponse(c, 400, "Invalid open_now")
+			return
+		}
+		filter.OpenNow = openNow
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	amenities, err := h.AmenityService.ListAmenities(ctx, code, filter)
+	if err != nil {
+		amenityError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Amenities retrieved", amenities)
+}
+
+// CreateAmenity godoc
+// @Summary Create an amenity
+// @Description Add an amenity or lounge to an airport (admin only)
+// @Tags airports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code path string true "Airport Code (IATA)"
+// @Param request body models.AmenityRequest true "Amenity"
+// @Success 201 {object} models.Amenity
+// @Router /api/airports/{code}/amenities [post]
+func (h *AirportController) CreateAmenity(c *gin.Context) {
+	code := c.Param("code")
+
+	if !utils.IsValidAirportCode(code) {
+		utils.ErrorResponse(c, 400, "Invalid airport code")
+		return
+	}
+
+	var req models.AmenityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	amenity, err := h.AmenityService.CreateAmenity(ctx, code, req)
+	if err != nil {
+		amenityError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 201, "Amenity created", amenity)
+}
+
+// UpdateAmenity godoc
+// @Summary Update an amenity
+// @Description Replace an amenity's details (admin only)
+// @Tags airports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code path string true "Airport Code (IATA)"
+// @Param id path string true "Amenity ID"
+// @Param request body models.AmenityRequest true "Amenity"
+// @Success 200 {object} models.Amenity
+// @Router /api/airports/{code}/amenities/{id} [put]
+func (h *AirportController) UpdateAmenity(c *gin.Context) {
+	code := c.Param("code")
+
+	if !utils.IsValidAirportCode(code) {
+		utils.ErrorResponse(c, 400, "Invalid airport code")
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, 400, "Invalid amenity ID")
+		return
+	}
+
+	var req models.AmenityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	amenity, err := h.AmenityService.UpdateAmenity(ctx, code, id, req)
+	if err != nil {
+		amenityError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Amenity updated", amenity)
+}
+
+// DeleteAmenity godoc
+// @Summary Delete an amenity
+// @Description Remove an amenity from an airport (admin only)
+// @Tags airports
+// @Produce json
+// @Security BearerAuth
+// @Param code path string true "Airport Code (IATA)"
+// @Param id path string true "Amenity ID"
+// @Success 200 {object} utils.Response
+// @Router /api/airports/{code}/amenities/{id} [delete]
+func (h *AirportController) DeleteAmenity(c *gin.Context) {
+	code := c.Param("code")
+
+	if !utils.IsValidAirportCode(code) {
+		utils.ErrorResponse(c, 400, "Invalid airport code")
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, 400, "Invalid amenity ID")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.AmenityService.DeleteAmenity(ctx, code, id); err != nil {
+		amenityError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Amenity deleted", nil)
+}
+
+// amenityError maps AmenityService errors to responses.
+func amenityError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrAirportNotFound),
+		errors.Is(err, services.ErrTerminalNotFound),
+		errors.Is(err, services.ErrAmenityNotFound):
+		utils.ErrorResponse(c, 404, err.Error())
+	case errors.Is(err, services.ErrInvalidHours):
+		utils.ErrorResponse(c, 400, err.Error())
+	default:
+		utils.ErrorResponse(c, 500, "Failed to process airport data")
+	}
+}
@@ -71,6 +71,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		Email:    req.Email,
 		Phone:    req.Phone,
 		Password: string(hashedPassword),
+		Locale:   req.Locale,
 		Preferences: models.UserPreferences{
 			NotifyGateChange:   true,
 			NotifyBoarding:     true,
@@ -152,49 +153,3 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		"user":  user,
 	})
 }
-
-// UpdateFCMToken godoc
-// @Summary Update FCM token
-// @Description Update user's Firebase Cloud Messaging token for push notifications
-// @Tags auth
-// @Accept json
-// @Produce json
-// @Security BearerAuth
-// @Param request body models.FCMTokenRequest true "FCM Token"
-// @Success 200 {object} utils.Response
-// @Router /api/auth/fcm-token [post]
-func (h *AuthHandler) UpdateFCMToken(c *gin.Context) {
-	userID := c.GetString("user_id")
-	if userID == "" {
-		utils.ErrorResponse(c, 401, "Unauthorized")
-		return
-	}
-
-	var req models.FCMTokenRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.ValidationErrorResponse(c, err)
-		return
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	objID, _ := primitive.ObjectIDFromHex(userID)
-	_, err := h.MongoDB.Users().UpdateOne(
-		ctx,
-		bson.M{"_id": objID},
-		bson.M{
-			"$set": bson.M{
-				"fcm_token":  req.FCMToken,
-				"updated_at": time.Now(),
-			},
-		},
-	)
-
-	if err != nil {
-		utils.ErrorResponse(c, 500, "Failed to update FCM token")
-		return
-	}
-
-	utils.SuccessResponse(c, 200, "FCM token updated successfully", nil)
-}
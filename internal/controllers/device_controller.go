@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onoja123/travel-companion-backend/internal/models"
+	"github.com/onoja123/travel-companion-backend/internal/services"
+	"github.com/onoja123/travel-companion-backend/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type DeviceHandler struct {
+	DeviceService *services.DeviceService
+}
+
+func NewDeviceHandler(deviceService *services.DeviceService) *DeviceHandler {
+	return &DeviceHandler{
+		DeviceService: deviceService,
+	}
+}
+
+// RegisterDevice godoc
+// @Summary Register a device for push notifications
+// @Description Register or refresh a device's Firebase Cloud Messaging token. Re-registering the same device_id replaces its token.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.RegisterDeviceRequest true "Device details"
+// @Success 200 {object} models.Device
+// @Router /api/auth/devices [post]
+func (h *DeviceHandler) RegisterDevice(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorResponse(c, 401, "Unauthorized")
+		return
+	}
+
+	var req models.RegisterDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, _ := primitive.ObjectIDFromHex(userID)
+	device, err := h.DeviceService.RegisterDevice(ctx, objID, req)
+	if err != nil {
+		utils.ErrorResponse(c, 500, "Failed to register device")
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Device registered successfully", device)
+}
+
+// GetDevices godoc
+// @Summary List registered devices
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Router /api/auth/devices [get]
+func (h *DeviceHandler) GetDevices(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorResponse(c, 401, "Unauthorized")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, _ := primitive.ObjectIDFromHex(userID)
+	devices, err := h.DeviceService.GetUserDevices(ctx, objID)
+	if err != nil {
+		utils.ErrorResponse(c, 500, "Failed to fetch devices")
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Devices retrieved", devices)
+}
+
+// UnregisterDevice godoc
+// @Summary Unregister a device
+// @Description Stop sending push notifications to a device, e.g. on sign-out
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param deviceId path string true "Device ID"
+// @Success 200 {object} utils.Response
+// @Router /api/auth/devices/{deviceId} [delete]
+func (h *DeviceHandler) UnregisterDevice(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorResponse(c, 401, "Unauthorized")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, _ := primitive.ObjectIDFromHex(userID)
+	if err := h.DeviceService.UnregisterDevice(ctx, objID, c.Param("deviceId")); err != nil {
+		utils.ErrorResponse(c, 404, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Device unregistered", nil)
+}
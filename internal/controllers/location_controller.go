@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -23,7 +24,7 @@ func NewLocationHandler(locationService *services.LocationService) *LocationHand
 
 // UpdateLocation godoc
 // @Summary Update user location
-// @Description Update current location for walk time calculations
+// @Description Update the authenticated user's current location for walk time calculations
 // @Tags location
 // @Accept json
 // @Produce json
@@ -32,11 +33,18 @@ func NewLocationHandler(locationService *services.LocationService) *LocationHand
 // @Success 200 {object} utils.Response
 // @Router /api/location/update [post]
 func (h *LocationHandler) UpdateLocation(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorResponse(c, 401, "Unauthorized")
+		return
+	}
+
 	var req models.LocationUpdate
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ValidationErrorResponse(c, err)
 		return
 	}
+	req.UserID = userID
 
 	// Validate coordinates
 	if req.Latitude < -90 || req.Latitude > 90 {
@@ -91,3 +99,215 @@ func (h *LocationHandler) GetWalkTime(c *gin.Context) {
 
 	utils.SuccessResponse(c, 200, "Walk time calculated", walkTime)
 }
+
+// GetMobility godoc
+// @Summary Get mobility profile
+// @Description Get the mobility profile and the walking pace used for walk times
+// @Tags location
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.MobilityResponse
+// @Router /api/location/mobility [get]
+func (h *LocationHandler) GetMobility(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorResponse(c, 401, "Unauthorized")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, _ := primitive.ObjectIDFromHex(userID)
+	mobility, err := h.LocationService.GetMobility(ctx, objID)
+	if err != nil {
+		utils.ErrorResponse(c, 404, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Mobility profile retrieved", mobility)
+}
+
+// UpdateMobility godoc
+// @Summary Update mobility profile
+// @Description Set a mobility profile (standard, wheelchair, with_children or assistance) that changes walking speed, avoids stairs and escalators in terminal routes and leaves more slack before boarding
+// @Tags location
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.MobilityProfileRequest true "Mobility profile"
+// @Success 200 {object} utils.Response
+// @Router /api/location/mobility [post]
+func (h *LocationHandler) UpdateMobility(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorResponse(c, 401, "Unauthorized")
+		return
+	}
+
+	var req models.MobilityProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, _ := primitive.ObjectIDFromHex(userID)
+	if err := h.LocationService.UpdateMobility(ctx, objID, req); err != nil {
+		utils.ErrorResponse(c, 500, "Failed to update mobility profile")
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Mobility profile updated successfully", nil)
+}
+
+// ResetPace godoc
+// @Summary Reset learned walking pace
+// @Description Forget the walking pace learned from location updates and fall back to the mobility profile
+// @Tags location
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Router /api/location/pace [delete]
+func (h *LocationHandler) ResetPace(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorResponse(c, 401, "Unauthorized")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, _ := primitive.ObjectIDFromHex(userID)
+	if err := h.LocationService.ResetPace(ctx, objID); err != nil {
+		utils.ErrorResponse(c, 500, "Failed to reset walking pace")
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Walking pace reset", nil)
+}
+
+// GetHistory godoc
+// @Summary Get location history
+// @Description Get the authenticated user's stored locations, newest first. Points outside an active trip are coarse (about 1 km)
+// @Tags location
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Start time (RFC 3339)"
+// @Param to query string false "End time (RFC 3339)"
+// @Param limit query int false "Maximum points (default and max 1000)"
+// @Success 200 {object} models.LocationHistoryResponse
+// @Router /api/location/history [get]
+func (h *LocationHandler) GetHistory(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorResponse(c, 401, "Unauthorized")
+		return
+	}
+
+	var from, to time.Time
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			utils.ErrorResponse(c, 400, "Invalid from time")
+			return
+		}
+		from = t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			utils.ErrorResponse(c, 400, "Invalid to time")
+			return
+		}
+		to = t
+	}
+
+	limit := 0
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 1000 {
+			utils.ErrorResponse(c, 400, "Invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, _ := primitive.ObjectIDFromHex(userID)
+	history, err := h.LocationService.GetHistory(ctx, objID, from, to, limit)
+	if err != nil {
+		utils.ErrorResponse(c, 500, "Failed to fetch location history")
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Location history retrieved", history)
+}
+
+// UpdateHistorySettings godoc
+// @Summary Update location history settings
+// @Description Opt in to or out of location history and choose how many days to keep it, up to the server maximum
+// @Tags location
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.HistorySettingsRequest true "History settings"
+// @Success 200 {object} utils.Response
+// @Router /api/location/history/settings [post]
+func (h *LocationHandler) UpdateHistorySettings(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorResponse(c, 401, "Unauthorized")
+		return
+	}
+
+	var req models.HistorySettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, _ := primitive.ObjectIDFromHex(userID)
+	if err := h.LocationService.UpdateHistorySettings(ctx, objID, req); err != nil {
+		utils.ErrorResponse(c, 400, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Location history settings updated successfully", nil)
+}
+
+// PurgeLocation godoc
+// @Summary Purge location data
+// @Description Delete the authenticated user's location history, current location and learned walking pace
+// @Tags location
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Router /api/location/history [delete]
+func (h *LocationHandler) PurgeLocation(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorResponse(c, 401, "Unauthorized")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, _ := primitive.ObjectIDFromHex(userID)
+	deleted, err := h.LocationService.PurgeLocation(ctx, objID)
+	if err != nil {
+		utils.ErrorResponse(c, 500, "Failed to purge location data")
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Location data purged", gin.H{"deleted_points": deleted})
+}
@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -23,12 +25,17 @@ func NewNotificationHandler(notificationService *services.NotificationService) *
 
 // GetNotifications godoc
 // @Summary Get user notifications
-// @Description Get all notifications for the authenticated user
+// @Description Get the authenticated user's notifications, newest first, with cursor pagination
 // @Tags notifications
 // @Produce json
 // @Security BearerAuth
 // @Param userId path string true "User ID"
-// @Success 200 {object} utils.Response
+// @Param cursor query string false "Cursor from a previous page's next_cursor"
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param type query string false "Notification type, e.g. gate_change"
+// @Param flight_key query string false "Flight key"
+// @Param unread query bool false "Only unread notifications"
+// @Success 200 {object} models.NotificationPage
 // @Router /api/notifications/{userId} [get]
 func (h *NotificationHandler) GetNotifications(c *gin.Context) {
 	userID := c.Param("userId")
@@ -39,17 +46,167 @@ func (h *NotificationHandler) GetNotifications(c *gin.Context) {
 		return
 	}
 
+	query := models.NotificationQuery{
+		Cursor:     c.Query("cursor"),
+		Type:       c.Query("type"),
+		FlightKey:  c.Query("flight_key"),
+		UnreadOnly: c.Query("unread") == "true",
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > 100 {
+			utils.ErrorResponse(c, 400, "Invalid limit")
+			return
+		}
+		query.Limit = n
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	objID, _ := primitive.ObjectIDFromHex(userID)
-	notifications, err := h.NotificationService.GetUserNotifications(ctx, objID)
+	page, err := h.NotificationService.GetUserNotifications(ctx, objID, query)
+	if errors.Is(err, services.ErrInvalidCursor) {
+		utils.ErrorResponse(c, 400, "Invalid cursor")
+		return
+	}
 	if err != nil {
 		utils.ErrorResponse(c, 500, "Failed to fetch notifications")
 		return
 	}
 
-	utils.SuccessResponse(c, 200, "Notifications retrieved", notifications)
+	utils.SuccessResponse(c, 200, "Notifications retrieved", page)
+}
+
+// GetUnreadCount godoc
+// @Summary Get unread notification count
+// @Description Get the number of unread notifications for the authenticated user
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Router /api/notifications/unread-count [get]
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorResponse(c, 401, "Unauthorized")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	objID, _ := primitive.ObjectIDFromHex(userID)
+	count, err := h.NotificationService.GetUnreadCount(ctx, objID)
+	if err != nil {
+		utils.ErrorResponse(c, 500, "Failed to count notifications")
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Unread count retrieved", gin.H{"unread": count})
+}
+
+// MarkRead godoc
+// @Summary Mark a notification as read
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Notification ID"
+// @Success 200 {object} utils.Response
+// @Router /api/notifications/{id}/read [post]
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorResponse(c, 401, "Unauthorized")
+		return
+	}
+
+	notificationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, 400, "Invalid notification ID")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+	err = h.NotificationService.MarkRead(ctx, notificationID, userObjID)
+	if errors.Is(err, services.ErrNotificationNotFound) {
+		utils.ErrorResponse(c, 404, err.Error())
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, 500, "Failed to mark notification as read")
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Notification marked as read", nil)
+}
+
+// MarkAllRead godoc
+// @Summary Mark all notifications as read
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Router /api/notifications/read-all [post]
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorResponse(c, 401, "Unauthorized")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, _ := primitive.ObjectIDFromHex(userID)
+	updated, err := h.NotificationService.MarkAllRead(ctx, objID)
+	if err != nil {
+		utils.ErrorResponse(c, 500, "Failed to mark notifications as read")
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Notifications marked as read", gin.H{"updated": updated})
+}
+
+// DeleteNotification godoc
+// @Summary Delete a notification
+// @Tags notifications
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Notification ID"
+// @Success 200 {object} utils.Response
+// @Router /api/notifications/{id} [delete]
+func (h *NotificationHandler) DeleteNotification(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorResponse(c, 401, "Unauthorized")
+		return
+	}
+
+	notificationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, 400, "Invalid notification ID")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	userObjID, _ := primitive.ObjectIDFromHex(userID)
+	err = h.NotificationService.DeleteNotification(ctx, notificationID, userObjID)
+	if errors.Is(err, services.ErrNotificationNotFound) {
+		utils.ErrorResponse(c, 404, err.Error())
+		return
+	}
+	if err != nil {
+		utils.ErrorResponse(c, 500, "Failed to delete notification")
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Notification deleted", nil)
 }
 
 // UpdatePreferences godoc
@@ -87,3 +244,39 @@ func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
 
 	utils.SuccessResponse(c, 200, "Preferences updated successfully", nil)
 }
+
+// UpdateDigestPreferences godoc
+// @Summary Update digest delivery settings
+// @Description Switch between instant pushes and a periodic digest, and choose the digest schedule and channel
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.DigestPreferencesRequest true "Digest settings"
+// @Success 200 {object} utils.Response
+// @Router /api/notifications/digest [post]
+func (h *NotificationHandler) UpdateDigestPreferences(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorResponse(c, 401, "Unauthorized")
+		return
+	}
+
+	var req models.DigestPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, _ := primitive.ObjectIDFromHex(userID)
+	err := h.NotificationService.UpdateDigestPreferences(ctx, objID, req)
+	if err != nil {
+		utils.ErrorResponse(c, 400, err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Digest preferences updated successfully", nil)
+}
@@ -63,6 +63,18 @@ func (m *MongoDB) Notifications() *mongo.Collection {
 	return m.Database.Collection("notifications")
 }
 
+func (m *MongoDB) Devices() *mongo.Collection {
+	return m.Database.Collection("devices")
+}
+
 func (m *MongoDB) Airports() *mongo.Collection {
 	return m.Database.Collection("airports")
 }
+
+func (m *MongoDB) Amenities() *mongo.Collection {
+	return m.Database.Collection("amenities")
+}
+
+func (m *MongoDB) LocationHistory() *mongo.Collection {
+	return m.Database.Collection("location_history")
+}
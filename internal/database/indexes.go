@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureIndexes creates the indexes the services rely on. CreateMany is a
+// no-op for indexes that already exist, so this is safe to run on startup.
+func (m *MongoDB) EnsureIndexes(ctx context.Context) error {
+	indexes := map[*mongo.Collection][]mongo.IndexModel{
+		m.Notifications(): {
+			{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "sent_at", Value: -1}, {Key: "_id", Value: -1}},
+				Options: options.Index().SetName("user_sent_at"),
+			},
+			{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "read_at", Value: 1}},
+				Options: options.Index().SetName("user_read_at"),
+			},
+			{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "delivery", Value: 1}, {Key: "digested_at", Value: 1}},
+				Options: options.Index().SetName("user_digest_pending"),
+			},
+		},
+		m.Airports(): {
+			{
+				Keys:    bson.D{{Key: "code", Value: 1}},
+				Options: options.Index().SetName("code").SetUnique(true),
+			},
+			{
+				// Airports without a location (not yet re-imported) are
+				// skipped by 2dsphere indexes and never match nearby.
+				Keys:    bson.D{{Key: "location", Value: "2dsphere"}},
+				Options: options.Index().SetName("location"),
+			},
+		},
+		m.Amenities(): {
+			{
+				Keys:    bson.D{{Key: "airport_code", Value: 1}, {Key: "category", Value: 1}},
+				Options: options.Index().SetName("airport_category"),
+			},
+		},
+		m.Users(): {
+			{
+				Keys:    bson.D{{Key: "preferences.delivery_mode", Value: 1}},
+				Options: options.Index().SetName("delivery_mode").SetSparse(true),
+			},
+		},
+		m.LocationHistory(): {
+			{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "recorded_at", Value: -1}},
+				Options: options.Index().SetName("user_recorded_at"),
+			},
+			{
+				Keys:    bson.D{{Key: "coarsen_at", Value: 1}},
+				Options: options.Index().SetName("coarsen_at").SetSparse(true),
+			},
+			{
+				// Each point carries its own expiry, so retention can
+				// differ per user and follow config changes.
+				Keys:    bson.D{{Key: "expires_at", Value: 1}},
+				Options: options.Index().SetName("expires_at").SetExpireAfterSeconds(0),
+			},
+		},
+		m.Devices(): {
+			{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "device_id", Value: 1}},
+				Options: options.Index().SetName("user_device").SetUnique(true),
+			},
+			{
+				Keys:    bson.D{{Key: "token", Value: 1}},
+				Options: options.Index().SetName("token"),
+			},
+		},
+	}
+
+	for collection, models := range indexes {
+		if _, err := collection.Indexes().CreateMany(ctx, models); err != nil {
+			return fmt.Errorf("failed to create indexes on %s: %w", collection.Name(), err)
+		}
+	}
+
+	return nil
+}
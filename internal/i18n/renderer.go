@@ -0,0 +1,293 @@
+package i18n
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// Channel identifies the delivery medium a message is rendered for.
+type Channel string
+
+const (
+	ChannelPush  Channel = "push"
+	ChannelEmail Channel = "email"
+	ChannelSMS   Channel = "sms"
+)
+
+// Message is the rendered output for a single channel.
+type Message struct {
+	Title string
+	Body  string
+}
+
+// Params carries everything a template needs to render.
+type Params struct {
+	Locale   string                 // BCP 47 tag, e.g. "en" or "es-MX"
+	Timezone string                 // IANA zone used by the localTime/localDate helpers
+	Data     map[string]interface{} // template fields
+}
+
+type catalogFile struct {
+	Locale     string                       `json:"locale"`
+	TimeFormat string                       `json:"time_format"`
+	DateFormat string                       `json:"date_format"`
+	Plurals    map[string]map[string]string `json:"plurals"`
+	Messages   map[string]messageSource     `json:"messages"`
+}
+
+type messageSource struct {
+	Title        string `json:"title"`
+	Body         string `json:"body"`
+	SMS          string `json:"sms,omitempty"`
+	EmailSubject string `json:"email_subject,omitempty"`
+	EmailBody    string `json:"email_body,omitempty"`
+}
+
+type catalog struct {
+	locale     string
+	timeFormat string
+	dateFormat string
+	plurals    map[string]map[string]string
+	messages   map[string]map[string]*template.Template // key -> field -> template
+}
+
+// Renderer renders notification content from per-locale message catalogs.
+type Renderer struct {
+	catalogs map[string]*catalog
+	fallback string
+}
+
+// NewRenderer loads the embedded catalogs. Messages missing from a locale
+// fall back to the catalog for fallbackLocale.
+func NewRenderer(fallbackLocale string) (*Renderer, error) {
+	entries, err := localeFS.ReadDir("locales")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read locale catalogs: %w", err)
+	}
+
+	r := &Renderer{
+		catalogs: make(map[string]*catalog),
+		fallback: normalizeLocale(fallbackLocale),
+	}
+
+	for _, entry := range entries {
+		raw, err := localeFS.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+		}
+
+		cat, err := parseCatalog(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid catalog %s: %w", entry.Name(), err)
+		}
+		r.catalogs[cat.locale] = cat
+	}
+
+	if _, ok := r.catalogs[r.fallback]; !ok {
+		return nil, fmt.Errorf("no catalog for fallback locale %q", fallbackLocale)
+	}
+
+	return r, nil
+}
+
+// Render renders the message identified by key for the given channel.
+func (r *Renderer) Render(channel Channel, key string, params Params) (*Message, error) {
+	cat, fields := r.lookup(params.Locale, key)
+	if fields == nil {
+		return nil, fmt.Errorf("unknown message %q", key)
+	}
+
+	loc := time.UTC
+	if params.Timezone != "" {
+		if l, err := time.LoadLocation(params.Timezone); err == nil {
+			loc = l
+		}
+	}
+
+	funcs := cat.funcs(loc)
+	exec := func(field string) (string, error) {
+		tmpl, ok := fields[field]
+		if !ok {
+			return "", nil
+		}
+
+		clone, err := tmpl.Clone()
+		if err != nil {
+			return "", err
+		}
+
+		var buf bytes.Buffer
+		if err := clone.Funcs(funcs).Execute(&buf, params.Data); err != nil {
+			return "", fmt.Errorf("failed to render %s.%s: %w", key, field, err)
+		}
+		return buf.String(), nil
+	}
+
+	title, err := exec("title")
+	if err != nil {
+		return nil, err
+	}
+	body, err := exec("body")
+	if err != nil {
+		return nil, err
+	}
+
+	switch channel {
+	case ChannelSMS:
+		sms, err := exec("sms")
+		if err != nil {
+			return nil, err
+		}
+		if sms == "" {
+			sms = title + ": " + body
+		}
+		return &Message{Body: sms}, nil
+
+	case ChannelEmail:
+		subject, err := exec("email_subject")
+		if err != nil {
+			return nil, err
+		}
+		emailBody, err := exec("email_body")
+		if err != nil {
+			return nil, err
+		}
+		if subject == "" {
+			subject = title
+		}
+		if emailBody == "" {
+			emailBody = body
+		}
+		return &Message{Title: subject, Body: emailBody}, nil
+	}
+
+	return &Message{Title: title, Body: body}, nil
+}
+
+// lookup resolves a locale such as "es-MX" to "es-mx", then "es", then the
+// fallback locale, returning the first catalog that defines key.
+func (r *Renderer) lookup(locale, key string) (*catalog, map[string]*template.Template) {
+	candidates := []string{normalizeLocale(locale)}
+	if base, _, found := strings.Cut(candidates[0], "-"); found {
+		candidates = append(candidates, base)
+	}
+	candidates = append(candidates, r.fallback)
+
+	for _, name := range candidates {
+		if cat, ok := r.catalogs[name]; ok {
+			if fields, ok := cat.messages[key]; ok {
+				return cat, fields
+			}
+		}
+	}
+	return nil, nil
+}
+
+func parseCatalog(raw []byte) (*catalog, error) {
+	var file catalogFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, err
+	}
+	if file.Locale == "" {
+		return nil, fmt.Errorf("missing locale")
+	}
+
+	cat := &catalog{
+		locale:     normalizeLocale(file.Locale),
+		timeFormat: file.TimeFormat,
+		dateFormat: file.DateFormat,
+		plurals:    file.Plurals,
+		messages:   make(map[string]map[string]*template.Template),
+	}
+	if cat.timeFormat == "" {
+		cat.timeFormat = "15:04"
+	}
+	if cat.dateFormat == "" {
+		cat.dateFormat = "2006-01-02"
+	}
+
+	// Helpers are rebound per render; these stubs only satisfy the parser.
+	stubs := cat.funcs(time.UTC)
+
+	for key, src := range file.Messages {
+		fields := map[string]string{
+			"title":         src.Title,
+			"body":          src.Body,
+			"sms":           src.SMS,
+			"email_subject": src.EmailSubject,
+			"email_body":    src.EmailBody,
+		}
+
+		parsed := make(map[string]*template.Template)
+		for field, text := range fields {
+			if text == "" {
+				continue
+			}
+			tmpl, err := template.New(key + "." + field).Funcs(stubs).Option("missingkey=zero").Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", key, field, err)
+			}
+			parsed[field] = tmpl
+		}
+		cat.messages[key] = parsed
+	}
+
+	return cat, nil
+}
+
+func (c *catalog) funcs(loc *time.Location) template.FuncMap {
+	return template.FuncMap{
+		"plural": func(word string, n int) string {
+			forms, ok := c.plurals[word]
+			if !ok {
+				return fmt.Sprintf("%d %s", n, word)
+			}
+			form, ok := forms[pluralCategory(c.locale, n)]
+			if !ok {
+				form = forms["other"]
+			}
+			return fmt.Sprintf(form, n)
+		},
+		"localTime": func(t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return t.In(loc).Format(c.timeFormat)
+		},
+		"localDate": func(t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return t.In(loc).Format(c.dateFormat)
+		},
+	}
+}
+
+// pluralCategory implements the CLDR cardinal rules for the shipped locales.
+func pluralCategory(locale string, n int) string {
+	base, _, _ := strings.Cut(locale, "-")
+	switch base {
+	case "fr":
+		if n == 0 || n == 1 {
+			return "one"
+		}
+	default:
+		if n == 1 {
+			return "one"
+		}
+	}
+	return "other"
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
@@ -0,0 +1,232 @@
+// Package importer loads airport reference data from OurAirports CSV
+// exports (https://ourairports.com/data/) into the airports collection.
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/onoja123/travel-companion-backend/internal/models"
+)
+
+// Filter selects which airports.csv rows are imported. Rows without an
+// IATA code are always skipped, since the API looks airports up by it.
+type Filter struct {
+	Types         []string // OurAirports types, e.g. large_airport
+	ScheduledOnly bool     // only airports with scheduled passenger service
+}
+
+// Record is an airport row together with its OurAirports ident, which
+// runways.csv refers to.
+type Record struct {
+	Ident   string
+	Airport models.Airport
+}
+
+// ReadAirports parses airports.csv.
+func ReadAirports(path string, filter Filter) ([]Record, error) {
+	rows, err := readCSV(path)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.close()
+
+	var records []Record
+	for rows.next() {
+		code := strings.ToUpper(rows.get("iata_code"))
+		if len(code) != 3 {
+			continue
+		}
+		if len(filter.Types) > 0 && !slices.Contains(filter.Types, rows.get("type")) {
+			continue
+		}
+		if filter.ScheduledOnly && rows.get("scheduled_service") != "yes" {
+			continue
+		}
+
+		lat, err := strconv.ParseFloat(rows.get("latitude_deg"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s row %d: invalid latitude", path, rows.line)
+		}
+		lon, err := strconv.ParseFloat(rows.get("longitude_deg"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s row %d: invalid longitude", path, rows.line)
+		}
+		elevation, _ := strconv.Atoi(rows.get("elevation_ft"))
+
+		icao := rows.get("icao_code")
+		if icao == "" {
+			icao = rows.get("gps_code")
+		}
+
+		records = append(records, Record{
+			Ident: rows.get("ident"),
+			Airport: models.Airport{
+				Code:        code,
+				ICAO:        icao,
+				Name:        rows.get("name"),
+				Type:        rows.get("type"),
+				City:        rows.get("municipality"),
+				Country:     rows.get("iso_country"),
+				Region:      rows.get("iso_region"),
+				Latitude:    lat,
+				Longitude:   lon,
+				Location:    models.NewGeoPoint(lat, lon),
+				ElevationFt: elevation,
+			},
+		})
+	}
+	if rows.err != nil {
+		return nil, rows.err
+	}
+
+	return records, nil
+}
+
+// ReadRunways parses runways.csv into open runways by airport ident.
+func ReadRunways(path string) (map[string][]models.Runway, error) {
+	rows, err := readCSV(path)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.close()
+
+	runways := make(map[string][]models.Runway)
+	for rows.next() {
+		if rows.get("closed") == "1" {
+			continue
+		}
+
+		ident := rows.get("le_ident")
+		if he := rows.get("he_ident"); he != "" {
+			ident += "/" + he
+		}
+		length, _ := strconv.Atoi(rows.get("length_ft"))
+		width, _ := strconv.Atoi(rows.get("width_ft"))
+
+		airport := rows.get("airport_ident")
+		runways[airport] = append(runways[airport], models.Runway{
+			Ident:    ident,
+			LengthFt: length,
+			WidthFt:  width,
+			Surface:  rows.get("surface"),
+			Lighted:  rows.get("lighted") == "1",
+		})
+	}
+	if rows.err != nil {
+		return nil, rows.err
+	}
+
+	return runways, nil
+}
+
+// ReadCountries parses countries.csv into country names by ISO code.
+func ReadCountries(path string) (map[string]string, error) {
+	rows, err := readCSV(path)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.close()
+
+	countries := make(map[string]string)
+	for rows.next() {
+		if code := rows.get("code"); code != "" {
+			countries[code] = rows.get("name")
+		}
+	}
+	if rows.err != nil {
+		return nil, rows.err
+	}
+
+	return countries, nil
+}
+
+// ReadTraffic parses annual passenger counts from a CSV with iata_code and
+// passengers columns. OurAirports has no traffic figures, so these come
+// from elsewhere, e.g. ACI or national statistics.
+func ReadTraffic(path string) (map[string]int64, error) {
+	rows, err := readCSV(path)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.close()
+
+	traffic := make(map[string]int64)
+	for rows.next() {
+		passengers, err := strconv.ParseInt(rows.get("passengers"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s row %d: invalid passengers", path, rows.line)
+		}
+		traffic[strings.ToUpper(rows.get("iata_code"))] = passengers
+	}
+	if rows.err != nil {
+		return nil, rows.err
+	}
+
+	return traffic, nil
+}
+
+// csvRows reads a CSV file with a header row, giving access to fields by
+// column name.
+type csvRows struct {
+	reader  *csv.Reader
+	file    *os.File
+	columns map[string]int
+	record  []string
+	line    int
+	err     error
+}
+
+func readCSV(path string) (*csvRows, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+
+	reader := csv.NewReader(f)
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to read %s header: %w", path, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	return &csvRows{reader: reader, file: f, columns: columns, line: 1}, nil
+}
+
+// next advances to the next record; at the end or on error it returns
+// false, leaving the error in err.
+func (r *csvRows) next() bool {
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return false
+	}
+	if err != nil {
+		r.err = fmt.Errorf("%s: %w", r.file.Name(), err)
+		return false
+	}
+	r.record = record
+	r.line++
+	return true
+}
+
+func (r *csvRows) close() {
+	r.file.Close()
+}
+
+func (r *csvRows) get(column string) string {
+	if i, ok := r.columns[column]; ok && i < len(r.record) {
+		return strings.TrimSpace(r.record[i])
+	}
+	return ""
+}
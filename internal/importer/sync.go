@@ -0,0 +1,193 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/onoja123/travel-companion-backend/internal/database"
+	"github.com/onoja123/travel-companion-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const writeBatchSize = 500
+
+// ErrNoTimezone fails an import that would store airports without a zone.
+var ErrNoTimezone = errors.New("no timezone for some airports")
+
+// Importer upserts airports by IATA code. It only writes the fields it
+// owns, so values maintained elsewhere, such as security_wait_avg, survive
+// re-imports, and unchanged airports are not written at all. Runways,
+// Countries and Traffic are optional; when nil the stored values are kept.
+// An import that leaves any airport without a timezone writes nothing,
+// unless AllowNoTimezone is set.
+type Importer struct {
+	MongoDB         *database.MongoDB
+	DryRun          bool
+	AllowNoTimezone bool
+	Runways         map[string][]models.Runway // by OurAirports ident
+	Countries       map[string]string          // names by ISO code
+	Traffic         map[string]int64           // annual passengers by IATA code
+}
+
+func NewImporter(db *database.MongoDB, dryRun bool) *Importer {
+	return &Importer{MongoDB: db, DryRun: dryRun}
+}
+
+// Change lists the fields an import changed on one airport.
+type Change struct {
+	Code   string
+	Fields []string
+}
+
+// Report summarizes an import.
+type Report struct {
+	Inserted   []string
+	Updated    []Change
+	Unchanged  int
+	Duplicates []string       // IATA codes on more than one row; the first is kept
+	NoTimezone []string       // airports stored without a zone
+	ZoneSource map[string]int // airports by where their zone came from
+}
+
+// Run imports records.
+func (imp *Importer) Run(ctx context.Context, records []Record, zones *Timezones) (*Report, error) {
+	existing, err := imp.existing(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{ZoneSource: make(map[string]int)}
+	seen := make(map[string]bool, len(records))
+	var writes []mongo.WriteModel
+
+	for _, record := range records {
+		airport := record.Airport
+		if seen[airport.Code] {
+			report.Duplicates = append(report.Duplicates, airport.Code)
+			continue
+		}
+		seen[airport.Code] = true
+
+		current, found := existing[airport.Code]
+
+		zone, source := zones.Resolve(airport)
+		if zone == "" && current.Timezone != "" {
+			zone, source = current.Timezone, ZoneFromExisting
+		}
+		airport.Timezone = zone
+		if zone == "" {
+			report.NoTimezone = append(report.NoTimezone, airport.Code)
+		} else {
+			report.ZoneSource[source]++
+		}
+
+		airport.Runways = current.Runways
+		if imp.Runways != nil {
+			airport.Runways = imp.Runways[record.Ident]
+		}
+		airport.CountryName = current.CountryName
+		if imp.Countries != nil {
+			airport.CountryName = imp.Countries[airport.Country]
+		}
+		airport.Passengers = current.Passengers
+		if imp.Traffic != nil {
+			airport.Passengers = imp.Traffic[airport.Code]
+		}
+
+		fields := ownedFields(airport)
+		var changed []string
+		if found {
+			before := ownedFields(current)
+			for _, name := range slices.Sorted(maps.Keys(fields)) {
+				if !reflect.DeepEqual(fields[name], before[name]) {
+					changed = append(changed, name)
+				}
+			}
+			if len(changed) == 0 {
+				report.Unchanged++
+				continue
+			}
+			report.Updated = append(report.Updated, Change{Code: airport.Code, Fields: changed})
+		} else {
+			report.Inserted = append(report.Inserted, airport.Code)
+		}
+
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"code": airport.Code}).
+			SetUpdate(bson.M{
+				"$set":         bson.M(fields),
+				"$setOnInsert": bson.M{"security_wait_avg": 0},
+			}).
+			SetUpsert(true))
+	}
+
+	if len(report.NoTimezone) > 0 && !imp.AllowNoTimezone {
+		return report, fmt.Errorf("%w: %s", ErrNoTimezone, strings.Join(report.NoTimezone, " "))
+	}
+
+	if imp.DryRun {
+		return report, nil
+	}
+
+	for start := 0; start < len(writes); start += writeBatchSize {
+		batch := writes[start:min(start+writeBatchSize, len(writes))]
+		opts := options.BulkWrite().SetOrdered(false)
+		if _, err := imp.MongoDB.Airports().BulkWrite(ctx, batch, opts); err != nil {
+			return report, fmt.Errorf("failed to write airports: %w", err)
+		}
+	}
+
+	return report, nil
+}
+
+func (imp *Importer) existing(ctx context.Context) (map[string]models.Airport, error) {
+	cursor, err := imp.MongoDB.Airports().Find(ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load airports: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var airports []models.Airport
+	if err := cursor.All(ctx, &airports); err != nil {
+		return nil, fmt.Errorf("failed to load airports: %w", err)
+	}
+
+	byCode := make(map[string]models.Airport, len(airports))
+	for _, a := range airports {
+		byCode[a.Code] = a
+	}
+	return byCode, nil
+}
+
+// ownedFields returns the importer-managed fields by their bson names.
+// Runways are compared and stored as a nil-normalized slice so an airport
+// without runways doesn't show as changed on every run.
+func ownedFields(a models.Airport) map[string]any {
+	runways := a.Runways
+	if len(runways) == 0 {
+		runways = []models.Runway{}
+	}
+	return map[string]any{
+		"icao":         a.ICAO,
+		"name":         a.Name,
+		"type":         a.Type,
+		"city":         a.City,
+		"country":      a.Country,
+		"country_name": a.CountryName,
+		"region":       a.Region,
+		"timezone":     a.Timezone,
+		"latitude":     a.Latitude,
+		"longitude":    a.Longitude,
+		"location":     a.Location,
+		"elevation_ft": a.ElevationFt,
+		"passengers":   a.Passengers,
+		"runways":      runways,
+	}
+}
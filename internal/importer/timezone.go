@@ -0,0 +1,162 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/models"
+)
+
+// OurAirports has no timezone column, so zones come from, in order: a
+// timezone file, the airport's ISO region, then its country. Regions and
+// countries are listed only when a single zone covers all of their
+// scheduled airports, apart from those in codeZones; airports elsewhere need
+// the file or keep the zone they already have. That leaves, in the US, AK,
+// FL, ID, IN, KS, KY, MI, ND, NE, OR, SD, TN and TX.
+var regionZones = map[string]string{
+	"US-AL": "America/Chicago", "US-AR": "America/Chicago", "US-AZ": "America/Phoenix",
+	"US-CA": "America/Los_Angeles", "US-CO": "America/Denver", "US-CT": "America/New_York",
+	"US-DC": "America/New_York", "US-DE": "America/New_York", "US-GA": "America/New_York",
+	"US-HI": "Pacific/Honolulu", "US-IA": "America/Chicago", "US-IL": "America/Chicago",
+	"US-LA": "America/Chicago", "US-MA": "America/New_York", "US-MD": "America/New_York",
+	"US-ME": "America/New_York", "US-MN": "America/Chicago", "US-MO": "America/Chicago",
+	"US-MS": "America/Chicago", "US-MT": "America/Denver", "US-NC": "America/New_York",
+	"US-NH": "America/New_York", "US-NJ": "America/New_York", "US-NM": "America/Denver",
+	"US-NV": "America/Los_Angeles", "US-NY": "America/New_York",
+	"US-OH": "America/New_York", "US-OK": "America/Chicago", "US-PA": "America/New_York",
+	"US-RI": "America/New_York", "US-SC": "America/New_York", "US-UT": "America/Denver",
+	"US-VA": "America/New_York", "US-VT": "America/New_York", "US-WA": "America/Los_Angeles",
+	"US-WI": "America/Chicago", "US-WV": "America/New_York", "US-WY": "America/Denver",
+	"AU-ACT": "Australia/Sydney", "AU-NSW": "Australia/Sydney", "AU-NT": "Australia/Darwin",
+	"AU-QLD": "Australia/Brisbane", "AU-SA": "Australia/Adelaide", "AU-TAS": "Australia/Hobart",
+	"AU-VIC": "Australia/Melbourne", "AU-WA": "Australia/Perth",
+}
+
+// codeZones are airports in a listed region that keep a zone of their own.
+var codeZones = map[string]string{
+	"BHQ": "Australia/Broken_Hill", // AU-NSW
+	"LDH": "Australia/Lord_Howe",   // AU-NSW
+}
+
+var countryZones = map[string]string{
+	"AE": "Asia/Dubai", "AR": "America/Argentina/Buenos_Aires", "AT": "Europe/Vienna",
+	"BB": "America/Barbados", "BD": "Asia/Dhaka", "BE": "Europe/Brussels", "BG": "Europe/Sofia",
+	"BH": "Asia/Bahrain", "BO": "America/La_Paz", "BS": "America/Nassau", "CH": "Europe/Zurich",
+	"CN": "Asia/Shanghai", "CO": "America/Bogota", "CR": "America/Costa_Rica", "CU": "America/Havana",
+	"CZ": "Europe/Prague", "DE": "Europe/Berlin", "DK": "Europe/Copenhagen", "DO": "America/Santo_Domingo",
+	"DZ": "Africa/Algiers", "EE": "Europe/Tallinn", "EG": "Africa/Cairo", "ET": "Africa/Addis_Ababa",
+	"FI": "Europe/Helsinki", "FR": "Europe/Paris", "GB": "Europe/London", "GH": "Africa/Accra",
+	"GR": "Europe/Athens", "GT": "America/Guatemala", "HK": "Asia/Hong_Kong", "HN": "America/Tegucigalpa",
+	"HR": "Europe/Zagreb", "HU": "Europe/Budapest", "IE": "Europe/Dublin", "IL": "Asia/Jerusalem",
+	"IN": "Asia/Kolkata", "IS": "Atlantic/Reykjavik", "IT": "Europe/Rome", "JM": "America/Jamaica",
+	"JO": "Asia/Amman", "JP": "Asia/Tokyo", "KE": "Africa/Nairobi", "KR": "Asia/Seoul",
+	"KW": "Asia/Kuwait", "LB": "Asia/Beirut", "LK": "Asia/Colombo", "LT": "Europe/Vilnius",
+	"LU": "Europe/Luxembourg", "LV": "Europe/Riga", "MA": "Africa/Casablanca", "MT": "Europe/Malta",
+	"MY": "Asia/Kuala_Lumpur", "NG": "Africa/Lagos", "NI": "America/Managua", "NL": "Europe/Amsterdam",
+	"NO": "Europe/Oslo", "NP": "Asia/Kathmandu", "OM": "Asia/Muscat", "PA": "America/Panama",
+	"PE": "America/Lima", "PH": "Asia/Manila", "PK": "Asia/Karachi", "PL": "Europe/Warsaw",
+	"PR": "America/Puerto_Rico", "PY": "America/Asuncion", "QA": "Asia/Qatar", "RO": "Europe/Bucharest",
+	"RS": "Europe/Belgrade", "RW": "Africa/Kigali", "SA": "Asia/Riyadh", "SE": "Europe/Stockholm",
+	"SG": "Asia/Singapore", "SI": "Europe/Ljubljana", "SK": "Europe/Bratislava", "SN": "Africa/Dakar",
+	"SV": "America/El_Salvador", "TH": "Asia/Bangkok", "TN": "Africa/Tunis", "TR": "Europe/Istanbul",
+	"TT": "America/Port_of_Spain", "TW": "Asia/Taipei", "TZ": "Africa/Dar_es_Salaam", "UG": "Africa/Kampala",
+	"UY": "America/Montevideo", "VE": "America/Caracas", "VN": "Asia/Ho_Chi_Minh", "ZA": "Africa/Johannesburg",
+}
+
+// Timezone sources, as reported in the import summary
+const (
+	ZoneFromFile     = "file"
+	ZoneFromRegion   = "region"
+	ZoneFromCountry  = "country"
+	ZoneFromExisting = "existing"
+)
+
+// Timezones resolves airport zones.
+type Timezones struct {
+	byCode map[string]string
+}
+
+// LoadTimezones reads IATA code to zone mappings, either as a CSV with
+// iata_code and timezone columns or as an OpenFlights airports.dat, whose
+// fifth and twelfth fields are the IATA code and the zone. An empty path
+// gives only the built-in region and country fallbacks.
+func LoadTimezones(path string) (*Timezones, error) {
+	t := &Timezones{byCode: make(map[string]string)}
+	if path == "" {
+		return t, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+
+	codeCol, zoneCol := 4, 11 // OpenFlights
+	first := true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		if first {
+			first = false
+			if header := columnIndex(record); header["iata_code"] >= 0 && header["timezone"] >= 0 {
+				codeCol, zoneCol = header["iata_code"], header["timezone"]
+				continue
+			}
+		}
+
+		if zoneCol >= len(record) || codeCol >= len(record) {
+			continue
+		}
+		code := strings.ToUpper(strings.TrimSpace(record[codeCol]))
+		zone := strings.TrimSpace(record[zoneCol])
+		if len(code) != 3 || zone == "" || zone == `\N` {
+			continue
+		}
+		if _, err := time.LoadLocation(zone); err != nil {
+			return nil, fmt.Errorf("%s: unknown timezone %q for %s", path, zone, code)
+		}
+		t.byCode[code] = zone
+	}
+
+	return t, nil
+}
+
+// Resolve returns the airport's zone and where it came from, or "" when
+// none is known.
+func (t *Timezones) Resolve(a models.Airport) (zone, source string) {
+	if zone, ok := t.byCode[a.Code]; ok {
+		return zone, ZoneFromFile
+	}
+	if zone, ok := codeZones[a.Code]; ok {
+		return zone, ZoneFromRegion
+	}
+	if zone, ok := regionZones[a.Region]; ok {
+		return zone, ZoneFromRegion
+	}
+	if zone, ok := countryZones[a.Country]; ok {
+		return zone, ZoneFromCountry
+	}
+	return "", ""
+}
+
+func columnIndex(header []string) map[string]int {
+	index := map[string]int{"iata_code": -1, "timezone": -1}
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	return index
+}
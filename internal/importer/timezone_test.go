@@ -0,0 +1,42 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/models"
+)
+
+func TestBuiltinZonesLoad(t *testing.T) {
+	for _, table := range []map[string]string{regionZones, codeZones, countryZones} {
+		for area, zone := range table {
+			if _, err := time.LoadLocation(zone); err != nil {
+				t.Errorf("%s: %v", area, err)
+			}
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	zones := &Timezones{byCode: map[string]string{"ELP": "America/Denver"}}
+
+	tests := []struct {
+		airport models.Airport
+		zone    string
+		source  string
+	}{
+		{models.Airport{Code: "ELP", Region: "US-TX", Country: "US"}, "America/Denver", ZoneFromFile},
+		{models.Airport{Code: "ABQ", Region: "US-NM", Country: "US"}, "America/Denver", ZoneFromRegion},
+		{models.Airport{Code: "SYD", Region: "AU-NSW", Country: "AU"}, "Australia/Sydney", ZoneFromRegion},
+		{models.Airport{Code: "BHQ", Region: "AU-NSW", Country: "AU"}, "Australia/Broken_Hill", ZoneFromRegion},
+		{models.Airport{Code: "LDH", Region: "AU-NSW", Country: "AU"}, "Australia/Lord_Howe", ZoneFromRegion},
+		{models.Airport{Code: "PEK", Region: "CN-11", Country: "CN"}, "Asia/Shanghai", ZoneFromCountry},
+		{models.Airport{Code: "DFW", Region: "US-TX", Country: "US"}, "", ""},
+	}
+
+	for _, tt := range tests {
+		if zone, source := zones.Resolve(tt.airport); zone != tt.zone || source != tt.source {
+			t.Errorf("%s: got %q from %q, want %q from %q", tt.airport.Code, zone, source, tt.zone, tt.source)
+		}
+	}
+}
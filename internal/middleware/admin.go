@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onoja123/travel-companion-backend/internal/database"
+	"github.com/onoja123/travel-companion-backend/internal/models"
+	"github.com/onoja123/travel-companion-backend/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RoleAdmin is the user role allowed to maintain airport reference data.
+const RoleAdmin = "admin"
+
+// AdminMiddleware lets through only admins. It runs after AuthMiddleware
+// and reads the role from the user record rather than the token, so
+// revoking it takes effect at once.
+func AdminMiddleware(db *database.MongoDB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := primitive.ObjectIDFromHex(c.GetString("user_id"))
+		if err != nil {
+			utils.ErrorResponse(c, 401, "Unauthorized")
+			c.Abort()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		var user models.User
+		opts := options.FindOne().SetProjection(bson.M{"role": 1})
+		if err := db.Users().FindOne(ctx, bson.M{"_id": userID}, opts).Decode(&user); err != nil || user.Role != RoleAdmin {
+			utils.ErrorResponse(c, 403, "Admin access required")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
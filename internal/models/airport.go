@@ -1,33 +1,136 @@
 package models
 
+import "time"
+
 type Airport struct {
-	Code            string  `bson:"code" json:"code"`
-	Name            string  `bson:"name" json:"name"`
-	City            string  `bson:"city" json:"city"`
-	Country         string  `bson:"country" json:"country"`
-	Timezone        string  `bson:"timezone" json:"timezone"`
-	SecurityWaitAvg int     `bson:"security_wait_avg" json:"security_wait_avg"` // minutes
-	Latitude        float64 `bson:"latitude" json:"latitude"`
-	Longitude       float64 `bson:"longitude" json:"longitude"`
+	Code            string     `bson:"code" json:"code"`
+	ICAO            string     `bson:"icao,omitempty" json:"icao,omitempty"`
+	Name            string     `bson:"name" json:"name"`
+	Type            string     `bson:"type,omitempty" json:"type,omitempty"` // large_airport, medium_airport, ...
+	City            string     `bson:"city" json:"city"`
+	Country         string     `bson:"country" json:"country"` // ISO 3166-1 alpha-2
+	CountryName     string     `bson:"country_name,omitempty" json:"country_name,omitempty"`
+	Region          string     `bson:"region,omitempty" json:"region,omitempty"` // ISO 3166-2, e.g. US-NY
+	Timezone        string     `bson:"timezone" json:"timezone"`
+	SecurityWaitAvg int        `bson:"security_wait_avg" json:"security_wait_avg"` // minutes
+	Latitude        float64    `bson:"latitude" json:"latitude"`
+	Longitude       float64    `bson:"longitude" json:"longitude"`
+	Location        *GeoPoint  `bson:"location,omitempty" json:"-"` // mirrors Latitude/Longitude for the 2dsphere index
+	ElevationFt     int        `bson:"elevation_ft,omitempty" json:"elevation_ft,omitempty"`
+	Passengers      int64      `bson:"passengers,omitempty" json:"passengers,omitempty"` // annual, ranks search results
+	Runways         []Runway   `bson:"runways,omitempty" json:"runways,omitempty"`
+	Terminals       []Terminal `bson:"terminals,omitempty" json:"terminals,omitempty"`
+}
+
+// GeoPoint is a GeoJSON point, as MongoDB's geospatial queries expect.
+type GeoPoint struct {
+	Type        string    `bson:"type" json:"type"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"` // longitude, latitude
+}
+
+func NewGeoPoint(lat, lon float64) *GeoPoint {
+	return &GeoPoint{Type: "Point", Coordinates: []float64{lon, lat}}
+}
+
+// AirportSummary is the short form of an airport returned by search.
+type AirportSummary struct {
+	Code        string  `json:"code"`
+	ICAO        string  `json:"icao,omitempty"`
+	Name        string  `json:"name"`
+	City        string  `json:"city"`
+	Country     string  `json:"country"`
+	CountryName string  `json:"country_name,omitempty"`
+	Type        string  `json:"type,omitempty"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+}
+
+// NearbyAirport is an airport within a search radius.
+type NearbyAirport struct {
+	AirportSummary
+	DistanceKm float64 `json:"distance_km"`
 }
 
+type Runway struct {
+	Ident    string `bson:"ident" json:"ident"` // both ends, e.g. "04L/22R"
+	LengthFt int    `bson:"length_ft,omitempty" json:"length_ft,omitempty"`
+	WidthFt  int    `bson:"width_ft,omitempty" json:"width_ft,omitempty"`
+	Surface  string `bson:"surface,omitempty" json:"surface,omitempty"`
+	Lighted  bool   `bson:"lighted" json:"lighted"`
+}
+
+// SecurityWaitTime is the current security queue: the wait published by
+// the airport or TSA, else the airport average. With crowd-sourced samples
+// it blends their median with that baseline, weighted by Confidence.
 type SecurityWaitTime struct {
-	AirportCode     string `json:"airport_code"`
-	CurrentWaitTime int    `json:"current_wait_time"` // minutes
-	Timestamp       string `json:"timestamp"`
+	AirportCode     string           `json:"airport_code"`
+	CurrentWaitTime int              `json:"current_wait_time"` // minutes
+	Source          string           `json:"source"`            // "average", "official" or "crowd"
+	Confidence      float64          `json:"confidence"`        // 0-1, from the sample count
+	SampleCount     int              `json:"sample_count"`      // dwell samples in the last hour
+	Checkpoints     []CheckpointWait `json:"checkpoints,omitempty"`
+	Timestamp       string           `json:"timestamp"`
+}
+
+// SecurityWaitForecast is the predicted security wait at a future time,
+// from the airport's hour-of-week model. Observations counts the hours
+// smoothed into the slot.
+type SecurityWaitForecast struct {
+	AirportCode  string           `json:"airport_code"`
+	At           time.Time        `json:"at"`
+	WaitMinutes  int              `json:"wait_minutes"`
+	Source       string           `json:"source"` // "forecast" or "average"
+	Confidence   float64          `json:"confidence"`
+	Observations int              `json:"observations"`
+	Holiday      string           `json:"holiday,omitempty"`
+	Checkpoints  []CheckpointWait `json:"checkpoints,omitempty"`
 }
 
+// CheckpointWait is the median landside-to-airside dwell at one checkpoint.
+type CheckpointWait struct {
+	Checkpoint  string  `json:"checkpoint,omitempty"`
+	WaitMinutes int     `json:"wait_minutes"`
+	Confidence  float64 `json:"confidence"`
+	SampleCount int     `json:"sample_count"`
+}
+
+// LocationUpdate is a GPS fix. UserID is always the authenticated user;
+// any value in the request body is ignored.
 type LocationUpdate struct {
-	UserID    string  `json:"user_id" binding:"required"`
+	UserID    string  `json:"-"`
 	Latitude  float64 `json:"latitude" binding:"required"`
 	Longitude float64 `json:"longitude" binding:"required"`
 	Timestamp string  `json:"timestamp" binding:"required"`
 }
 
+// WalkTimeResponse is the time-to-gate estimate: travel to the airport,
+// the security queue and the walk to the gate, against boarding time.
 type WalkTimeResponse struct {
-	FlightID          string  `json:"flight_id"`
-	DistanceMeters    float64 `json:"distance_meters"`
-	WalkTimeMinutes   int     `json:"walk_time_minutes"`
-	UrgencyLevel      string  `json:"urgency_level"`
-	RecommendedAction string  `json:"recommended_action"`
+	FlightID            string      `json:"flight_id"`
+	Gate                string      `json:"gate,omitempty"`
+	Position            string      `json:"position"` // off_airport, landside or airside
+	DistanceMeters      float64     `json:"distance_meters"`
+	WalkTimeMinutes     int         `json:"walk_time_minutes"`
+	WalkingSpeed        float64     `json:"walking_speed"` // meters per second, from the mobility profile or learned pace
+	TravelMinutes       int         `json:"travel_minutes"`
+	SecurityWaitMinutes int         `json:"security_wait_minutes"`
+	TotalMinutes        int         `json:"total_minutes"`
+	LeaveBy             *time.Time  `json:"leave_by,omitempty"`
+	UrgencyLevel        string      `json:"urgency_level"`
+	RecommendedAction   string      `json:"recommended_action"`
+	Method              string      `json:"method"` // terminal_graph, straight_line or estimate
+	Route               []RouteStep `json:"route,omitempty"`
+}
+
+// RouteStep is one node on the path to the gate, reached via Mode
+// (walkway, train, security, ...).
+type RouteStep struct {
+	NodeID    string  `json:"node_id"`
+	Name      string  `json:"name,omitempty"`
+	Type      string  `json:"type"`
+	Terminal  string  `json:"terminal,omitempty"`
+	Mode      string  `json:"mode,omitempty"`
+	Seconds   int     `json:"seconds"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
 }
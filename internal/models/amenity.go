@@ -0,0 +1,91 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Terminal is a terminal building and its gates, stored on the airport.
+type Terminal struct {
+	Code  string `bson:"code" json:"code" binding:"required"` // as on departure boards, e.g. "4" or "T4"
+	Name  string `bson:"name,omitempty" json:"name,omitempty"`
+	Gates []Gate `bson:"gates" json:"gates" binding:"dive"`
+}
+
+type Gate struct {
+	Label     string `bson:"label" json:"label" binding:"required"`          // e.g. "B32"
+	Concourse string `bson:"concourse,omitempty" json:"concourse,omitempty"` // defaults to the label's letters
+}
+
+// Amenity categories
+const (
+	AmenityFood     = "food"
+	AmenityDrink    = "drink"
+	AmenityCoffee   = "coffee"
+	AmenityShop     = "shop"
+	AmenityLounge   = "lounge"
+	AmenityRestroom = "restroom"
+	AmenityPharmacy = "pharmacy"
+	AmenityCharging = "charging"
+	AmenityOther    = "other"
+)
+
+// Amenity is a place in a terminal: a restaurant, shop, lounge, restroom
+// and so on. NearGates lists the gates it is signposted from; coordinates
+// let walk times be routed through the terminal graph instead.
+type Amenity struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	AirportCode string             `bson:"airport_code" json:"airport_code"`
+	Terminal    string             `bson:"terminal,omitempty" json:"terminal,omitempty"`
+	Name        string             `bson:"name" json:"name"`
+	Category    string             `bson:"category" json:"category"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	Airside     bool               `bson:"airside" json:"airside"` // past security
+	NearGates   []string           `bson:"near_gates,omitempty" json:"near_gates,omitempty"`
+	Latitude    float64            `bson:"latitude,omitempty" json:"latitude,omitempty"`
+	Longitude   float64            `bson:"longitude,omitempty" json:"longitude,omitempty"`
+	Open24Hours bool               `bson:"open_24_hours" json:"open_24_hours"`
+	Hours       []OpeningHours     `bson:"hours,omitempty" json:"hours,omitempty"` // airport local time
+	Lounge      *LoungeDetails     `bson:"lounge,omitempty" json:"lounge,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// OpeningHours is one opening period on the given days. A Close at or
+// before Open runs past midnight; "24:00" closes at the end of the day.
+type OpeningHours struct {
+	Days  []string `bson:"days" json:"days" binding:"required,min=1,dive,oneof=mon tue wed thu fri sat sun"`
+	Open  string   `bson:"open" json:"open" binding:"required"`   // "HH:MM"
+	Close string   `bson:"close" json:"close" binding:"required"` // "HH:MM"
+}
+
+// LoungeDetails describes who can use a lounge.
+type LoungeDetails struct {
+	Access   []string `bson:"access,omitempty" json:"access,omitempty"`     // e.g. "business_class", "priority_pass", "day_pass"
+	Airlines []string `bson:"airlines,omitempty" json:"airlines,omitempty"` // IATA codes of airlines whose passengers get in
+	Showers  bool     `bson:"showers" json:"showers"`
+}
+
+type AmenityRequest struct {
+	Terminal    string         `json:"terminal"`
+	Name        string         `json:"name" binding:"required"`
+	Category    string         `json:"category" binding:"required,oneof=food drink coffee shop lounge restroom pharmacy charging other"`
+	Description string         `json:"description"`
+	Airside     bool           `json:"airside"`
+	NearGates   []string       `json:"near_gates"`
+	Latitude    float64        `json:"latitude" binding:"min=-90,max=90"`
+	Longitude   float64        `json:"longitude" binding:"min=-180,max=180"`
+	Open24Hours bool           `json:"open_24_hours"`
+	Hours       []OpeningHours `json:"hours" binding:"dive"`
+	Lounge      *LoungeDetails `json:"lounge"`
+}
+
+// AmenityResult is an amenity as listed to travelers. OpenNow is omitted
+// when the amenity has no hours; WalkMinutes when there is no terminal
+// graph to route through.
+type AmenityResult struct {
+	Amenity
+	OpenNow     *bool `json:"open_now,omitempty"`
+	WalkMinutes *int  `json:"walk_minutes,omitempty"` // from the near_gate gate
+}
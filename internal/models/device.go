@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Device is a push-capable installation of the app. A user can have many.
+type Device struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"user_id"`
+	DeviceID   string             `bson:"device_id" json:"device_id"`
+	Platform   string             `bson:"platform" json:"platform"` // "ios", "android", "web"
+	Token      string             `bson:"token" json:"-"`
+	AppVersion string             `bson:"app_version,omitempty" json:"app_version,omitempty"`
+	LastSeen   time.Time          `bson:"last_seen" json:"last_seen"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+type RegisterDeviceRequest struct {
+	DeviceID   string `json:"device_id" binding:"required"`
+	Platform   string `json:"platform" binding:"required,oneof=ios android web"`
+	FCMToken   string `json:"fcm_token" binding:"required"`
+	AppVersion string `json:"app_version,omitempty"`
+}
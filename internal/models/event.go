@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// Realtime event types pushed to connected clients.
+const (
+	EventGateChanged       = "flight.gate_changed"
+	EventStatusChanged     = "flight.status_changed"
+	EventDelayChanged      = "flight.delay_changed"
+	EventBoardingCountdown = "flight.boarding_countdown"
+
+	EventAirportEntered = "airport.entered"
+	EventSecurityPassed = "airport.security_passed"
+	EventAirportLeft    = "airport.left"
+)
+
+// Event is the envelope for every realtime message sent to clients.
+type Event struct {
+	Type      string      `json:"type"`
+	Seq       int64       `json:"seq,omitempty"` // per-user sequence number, for resuming
+	FlightKey string      `json:"flight_key,omitempty"`
+	Data      interface{} `json:"data"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// Audience selects who receives an event: the live connections of specific
+// users plus every connection subscribed to one of the topics. A connection
+// matching more than once receives the event once.
+type Audience struct {
+	UserIDs []string `json:"user_ids,omitempty"`
+	Topics  []string `json:"topics,omitempty"`
+}
+
+// FlightTopic is the topic for everyone watching a flight.
+func FlightTopic(flightKey string) string {
+	return "flight:" + flightKey
+}
+
+// TripTopic is the topic for a single tracked flight; only its owner may
+// subscribe.
+func TripTopic(trackedFlightID string) string {
+	return "trip:" + trackedFlightID
+}
+
+type GateChangedEvent struct {
+	OldGate  string `json:"old_gate"`
+	NewGate  string `json:"new_gate"`
+	Terminal string `json:"terminal,omitempty"`
+}
+
+type StatusChangedEvent struct {
+	OldStatus string `json:"old_status"`
+	NewStatus string `json:"new_status"`
+}
+
+type DelayChangedEvent struct {
+	OldDelayMinutes int       `json:"old_delay_minutes"`
+	NewDelayMinutes int       `json:"new_delay_minutes"`
+	DepartureTime   time.Time `json:"departure_time"`
+}
+
+type BoardingCountdownEvent struct {
+	Gate             string `json:"gate,omitempty"`
+	BoardingMinutes  int    `json:"boarding_minutes"`
+	DepartureMinutes int    `json:"departure_minutes"`
+	UrgencyLevel     string `json:"urgency_level"`
+}
+
+// GeofenceEvent is the payload of airport.entered, airport.security_passed
+// and airport.left.
+type GeofenceEvent struct {
+	AirportCode         string `json:"airport_code"`
+	Terminal            string `json:"terminal,omitempty"`
+	Zone                string `json:"zone"` // landside, airside or outside
+	SecurityWaitMinutes int    `json:"security_wait_minutes,omitempty"`
+}
@@ -20,19 +20,22 @@ type TrackedFlight struct {
 }
 
 type FlightStatus struct {
-	FlightKey     string      `bson:"flight_key" json:"flight_key"`
-	FlightNumber  string      `bson:"flight_number" json:"flight_number"`
-	AirlineCode   string      `bson:"airline_code" json:"airline_code"`
-	Status        string      `bson:"status" json:"status"`
-	Gate          string      `bson:"gate,omitempty" json:"gate,omitempty"`
-	Terminal      string      `bson:"terminal,omitempty" json:"terminal,omitempty"`
-	BoardingTime  time.Time   `bson:"boarding_time,omitempty" json:"boarding_time,omitempty"`
-	DepartureTime time.Time   `bson:"departure_time" json:"departure_time"`
-	ArrivalTime   time.Time   `bson:"arrival_time" json:"arrival_time"`
-	DelayMinutes  int         `bson:"delay_minutes" json:"delay_minutes"`
-	GateChange    *GateChange `bson:"gate_change,omitempty" json:"gate_change,omitempty"`
-	LastUpdated   time.Time   `bson:"last_updated" json:"last_updated"`
-	RawData       interface{} `bson:"raw_data,omitempty" json:"raw_data,omitempty"`
+	FlightKey        string      `bson:"flight_key" json:"flight_key"`
+	FlightNumber     string      `bson:"flight_number" json:"flight_number"`
+	AirlineCode      string      `bson:"airline_code" json:"airline_code"`
+	DepartureAirport string      `bson:"departure_airport,omitempty" json:"departure_airport,omitempty"`
+	ArrivalAirport   string      `bson:"arrival_airport,omitempty" json:"arrival_airport,omitempty"`
+	Status           string      `bson:"status" json:"status"`
+	Gate             string      `bson:"gate,omitempty" json:"gate,omitempty"`
+	Terminal         string      `bson:"terminal,omitempty" json:"terminal,omitempty"`
+	BoardingTime     time.Time   `bson:"boarding_time,omitempty" json:"boarding_time,omitempty"`
+	DepartureTime    time.Time   `bson:"departure_time" json:"departure_time"`
+	ArrivalTime      time.Time   `bson:"arrival_time" json:"arrival_time"`
+	DelayMinutes     int         `bson:"delay_minutes" json:"delay_minutes"`
+	Timezone         string      `bson:"timezone,omitempty" json:"timezone,omitempty"` // departure airport IANA zone
+	GateChange       *GateChange `bson:"gate_change,omitempty" json:"gate_change,omitempty"`
+	LastUpdated      time.Time   `bson:"last_updated" json:"last_updated"`
+	RawData          interface{} `bson:"raw_data,omitempty" json:"raw_data,omitempty"`
 }
 
 type GateChange struct {
@@ -51,9 +54,10 @@ type TrackFlightRequest struct {
 }
 
 type FlightStatusResponse struct {
-	Flight       FlightStatus `json:"flight"`
-	TimeUntil    TimeUntil    `json:"time_until"`
-	UrgencyLevel string       `json:"urgency_level"` // "calm", "moderate", "urgent", "critical"
+	Flight       FlightStatus      `json:"flight"`
+	TimeUntil    TimeUntil         `json:"time_until"`
+	UrgencyLevel string            `json:"urgency_level"` // "calm", "moderate", "urgent", "critical"
+	WeatherRisk  *WeatherDelayRisk `json:"weather_risk,omitempty"`
 }
 
 type TimeUntil struct {
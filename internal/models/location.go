@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// HistorySettings is the user's opt-in to keeping past locations.
+type HistorySettings struct {
+	Enabled       bool `bson:"enabled" json:"enabled"`
+	RetentionDays int  `bson:"retention_days,omitempty" json:"retention_days,omitempty"` // 0 keeps the server maximum
+}
+
+type HistorySettingsRequest struct {
+	Enabled       bool `json:"enabled"`
+	RetentionDays int  `json:"retention_days" binding:"min=0"`
+}
+
+// LocationPoint is one stored fix. Points recorded during a trip keep full
+// precision until CoarsenAt, the end of that trip; all others are stored
+// coarse. ExpiresAt drives a TTL index.
+type LocationPoint struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID  `bson:"user_id" json:"-"`
+	FlightID   *primitive.ObjectID `bson:"flight_id,omitempty" json:"flight_id,omitempty"`
+	Latitude   float64             `bson:"latitude" json:"latitude"`
+	Longitude  float64             `bson:"longitude" json:"longitude"`
+	Precise    bool                `bson:"precise" json:"precise"`
+	RecordedAt time.Time           `bson:"recorded_at" json:"recorded_at"`
+	CoarsenAt  *time.Time          `bson:"coarsen_at,omitempty" json:"-"`
+	ExpiresAt  time.Time           `bson:"expires_at" json:"-"`
+}
+
+type LocationHistoryResponse struct {
+	Settings HistorySettings `json:"settings"`
+	Points   []LocationPoint `json:"points"`
+}
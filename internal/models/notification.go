@@ -16,6 +16,23 @@ type Notification struct {
 	Priority  string             `bson:"priority" json:"priority"` // "normal", "high"
 	SentAt    time.Time          `bson:"sent_at" json:"sent_at"`
 	ReadAt    *time.Time         `bson:"read_at,omitempty" json:"read_at,omitempty"`
+
+	Delivery   string     `bson:"delivery,omitempty" json:"delivery,omitempty"` // "instant" or "digest"
+	DigestedAt *time.Time `bson:"digested_at,omitempty" json:"digested_at,omitempty"`
+}
+
+// NotificationQuery filters and paginates a user's inbox.
+type NotificationQuery struct {
+	Cursor     string
+	Limit      int
+	Type       string
+	FlightKey  string
+	UnreadOnly bool
+}
+
+type NotificationPage struct {
+	Notifications []Notification `json:"notifications"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
 }
 
 type NotificationPreferencesRequest struct {
@@ -26,3 +43,11 @@ type NotificationPreferencesRequest struct {
 	BoardingReminder20 bool `json:"boarding_reminder_20"`
 	BoardingReminder10 bool `json:"boarding_reminder_10"`
 }
+
+type DigestPreferencesRequest struct {
+	DeliveryMode    string `json:"delivery_mode" binding:"required,oneof=instant digest"`
+	DigestFrequency string `json:"digest_frequency" binding:"omitempty,oneof=hourly daily"`
+	DigestChannel   string `json:"digest_channel" binding:"omitempty,oneof=push email sms"`
+	DigestHour      int    `json:"digest_hour" binding:"min=0,max=23"`
+	DigestTimezone  string `json:"digest_timezone,omitempty"`
+}
@@ -11,8 +11,12 @@ type User struct {
 	Email       string             `bson:"email" json:"email" binding:"required,email"`
 	Phone       string             `bson:"phone,omitempty" json:"phone,omitempty"`
 	Password    string             `bson:"password" json:"-"`
-	FCMToken    string             `bson:"fcm_token,omitempty" json:"fcm_token,omitempty"`
+	Role        string             `bson:"role,omitempty" json:"role,omitempty"`     // "admin" for airport data maintainers
+	Locale      string             `bson:"locale,omitempty" json:"locale,omitempty"` // BCP 47, e.g. "en", "es-MX"
 	Preferences UserPreferences    `bson:"preferences" json:"preferences"`
+	Mobility    MobilityProfile    `bson:"mobility" json:"mobility"`
+	History     HistorySettings    `bson:"location_history" json:"location_history"`
+	LastDigest  *time.Time         `bson:"last_digest_at,omitempty" json:"-"`
 	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
 }
@@ -24,12 +28,44 @@ type UserPreferences struct {
 	BoardingReminder40 bool `bson:"boarding_reminder_40" json:"boarding_reminder_40"`
 	BoardingReminder20 bool `bson:"boarding_reminder_20" json:"boarding_reminder_20"`
 	BoardingReminder10 bool `bson:"boarding_reminder_10" json:"boarding_reminder_10"`
+
+	// Digest delivery batches notifications into one summary per period
+	// instead of pushing each one.
+	DeliveryMode    string `bson:"delivery_mode,omitempty" json:"delivery_mode,omitempty"`       // "instant" (default) or "digest"
+	DigestFrequency string `bson:"digest_frequency,omitempty" json:"digest_frequency,omitempty"` // "hourly" or "daily"
+	DigestChannel   string `bson:"digest_channel,omitempty" json:"digest_channel,omitempty"`     // "push", "email" or "sms"
+	DigestHour      int    `bson:"digest_hour" json:"digest_hour"`                               // local hour for daily digests
+	DigestTimezone  string `bson:"digest_timezone,omitempty" json:"digest_timezone,omitempty"`   // IANA zone for DigestHour
+}
+
+// MobilityProfile changes walking speed, which terminal routes are allowed
+// and how much slack walk-time urgency leaves.
+type MobilityProfile struct {
+	Profile     string `bson:"profile,omitempty" json:"profile,omitempty"` // "standard" (default), "wheelchair", "with_children" or "assistance"
+	AvoidStairs bool   `bson:"avoid_stairs" json:"avoid_stairs"`
+}
+
+type MobilityProfileRequest struct {
+	Profile     string `json:"profile" binding:"required,oneof=standard wheelchair with_children assistance"`
+	AvoidStairs bool   `json:"avoid_stairs"`
+}
+
+// MobilityResponse is the profile plus the pace walk times use: the
+// learned pace once there are enough samples, the profile's otherwise.
+type MobilityResponse struct {
+	Profile        string   `json:"profile"`
+	AvoidStairs    bool     `json:"avoid_stairs"`
+	WalkingSpeed   float64  `json:"walking_speed"` // meters per second
+	LearnedSpeed   float64  `json:"learned_speed,omitempty"`
+	LearnedSamples int      `json:"learned_samples"`
+	Avoids         []string `json:"avoids,omitempty"` // terminal edge types routes skip
 }
 
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
 	Phone    string `json:"phone,omitempty"`
+	Locale   string `json:"locale,omitempty"`
 }
 
 type LoginRequest struct {
@@ -41,7 +77,3 @@ type LoginResponse struct {
 	Token string `json:"token"`
 	User  User   `json:"user"`
 }
-
-type FCMTokenRequest struct {
-	FCMToken string `json:"fcm_token" binding:"required"`
-}
@@ -0,0 +1,94 @@
+package models
+
+import "time"
+
+// Delay risk levels
+const (
+	DelayRiskLow      = "low"
+	DelayRiskModerate = "moderate"
+	DelayRiskHigh     = "high"
+)
+
+// Conditions is the weather in a METAR or TAF period. Unreported elements
+// are nil, so a TAF change group only carries what it changes.
+type Conditions struct {
+	Wind        *Wind        `json:"wind,omitempty"`
+	VisibilityM *int         `json:"visibility_m,omitempty"` // 10000 means 10 km or more
+	CeilingFt   *int         `json:"ceiling_ft,omitempty"`   // lowest broken or overcast layer, or vertical visibility
+	Clouds      []CloudLayer `json:"clouds,omitempty"`
+	SkyClear    bool         `json:"sky_clear,omitempty"` // CAVOK, SKC, CLR, NSC or NCD
+	Phenomena   []string     `json:"phenomena,omitempty"` // e.g. "+TSRA", "BR", "FZFG"
+	NoWeather   bool         `json:"-"`                   // NSW: earlier phenomena have ended
+}
+
+type Wind struct {
+	DirectionDeg int  `json:"direction_deg"`
+	Variable     bool `json:"variable,omitempty"`
+	SpeedKt      int  `json:"speed_kt"`
+	GustKt       int  `json:"gust_kt,omitempty"`
+}
+
+type CloudLayer struct {
+	Cover  string `json:"cover"`          // FEW, SCT, BKN, OVC or VV
+	BaseFt int    `json:"base_ft"`        // above ground
+	Type   string `json:"type,omitempty"` // CB or TCU
+}
+
+// METAR is a routine or special airport weather observation.
+type METAR struct {
+	Conditions
+	Station      string    `json:"station"`
+	ObservedAt   time.Time `json:"observed_at"`
+	TemperatureC *int      `json:"temperature_c,omitempty"`
+	DewpointC    *int      `json:"dewpoint_c,omitempty"`
+	Raw          string    `json:"raw"`
+}
+
+// TAF is a terminal aerodrome forecast.
+type TAF struct {
+	Station   string      `json:"station"`
+	IssuedAt  time.Time   `json:"issued_at"`
+	ValidFrom time.Time   `json:"valid_from"`
+	ValidTo   time.Time   `json:"valid_to"`
+	Periods   []TAFPeriod `json:"periods"`
+	Raw       string      `json:"raw"`
+}
+
+// TAFPeriod is the forecast's opening conditions ("BASE") or a change
+// group: FM replaces the conditions from its start, BECMG changes some of
+// them, and TEMPO and PROB30/PROB40 are temporary or possible.
+type TAFPeriod struct {
+	Conditions
+	Change string    `json:"change"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+}
+
+// DelayRisk is a weather-driven hint of how likely delays are at an
+// airport around a time, from the observation near now and the forecast
+// later.
+type DelayRisk struct {
+	Airport string    `json:"airport"`
+	At      time.Time `json:"at"`
+	Level   string    `json:"level"`             // "low", "moderate" or "high"
+	Reasons []string  `json:"reasons,omitempty"` // e.g. "thunderstorms", "low ceiling (300 ft)"
+	Basis   string    `json:"basis"`             // "observed" or "forecast"
+}
+
+// AirportWeather is an airport's latest METAR and TAF.
+type AirportWeather struct {
+	AirportCode string     `json:"airport_code"`
+	Station     string     `json:"station"`
+	METAR       *METAR     `json:"metar,omitempty"`
+	TAF         *TAF       `json:"taf,omitempty"`
+	DelayRisk   *DelayRisk `json:"delay_risk,omitempty"`
+	Source      string     `json:"source"`
+	FetchedAt   time.Time  `json:"fetched_at"`
+}
+
+// WeatherDelayRisk is the delay risk at both ends of a flight.
+type WeatherDelayRisk struct {
+	Level     string     `json:"level"` // the higher of the two
+	Departure *DelayRisk `json:"departure,omitempty"`
+	Arrival   *DelayRisk `json:"arrival,omitempty"`
+}
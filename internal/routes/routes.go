@@ -3,24 +3,44 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	handlers "github.com/onoja123/travel-companion-backend/internal/controllers"
+	ws "github.com/onoja123/travel-companion-backend/internal/websocket"
 )
 
 // RegisterRoutes registers all API routes to the Gin router
 func RegisterRoutes(router *gin.Engine,
+	authMiddleware gin.HandlerFunc,
+	adminMiddleware gin.HandlerFunc,
 	airportController *handlers.AirportController,
 	authController *handlers.AuthHandler,
+	deviceController *handlers.DeviceHandler,
 	flightController *handlers.FlightHandler,
 	locationController *handlers.LocationHandler,
 	notificationController *handlers.NotificationHandler,
+	wsHandler *ws.Handler,
 ) {
 	// Auth routes
 	router.POST("/api/auth/register", authController.Register)
 	router.POST("/api/auth/login", authController.Login)
-	router.POST("/api/auth/fcm-token", authController.UpdateFCMToken)
+	router.GET("/api/auth/devices", authMiddleware, deviceController.GetDevices)
+	router.POST("/api/auth/devices", authMiddleware, deviceController.RegisterDevice)
+	router.DELETE("/api/auth/devices/:deviceId", authMiddleware, deviceController.UnregisterDevice)
 
 	// Airport routes
+	router.GET("/api/airports/search", airportController.SearchAirports)
+	router.GET("/api/airports/nearby", airportController.NearbyAirports)
 	router.GET("/api/airports/:code", airportController.GetAirport)
 	router.GET("/api/airports/:code/security-wait", airportController.GetSecurityWaitTime)
+	router.GET("/api/airports/:code/security-wait/forecast", airportController.ForecastSecurityWait)
+	router.GET("/api/airports/:code/weather", airportController.GetWeather)
+	router.GET("/api/airports/:code/terminals", airportController.GetTerminals)
+	router.GET("/api/airports/:code/amenities", airportController.GetAmenities)
+
+	// Airport data admin routes
+	router.PUT("/api/airports/:code/terminals/:terminal", authMiddleware, adminMiddleware, airportController.PutTerminal)
+	router.DELETE("/api/airports/:code/terminals/:terminal", authMiddleware, adminMiddleware, airportController.DeleteTerminal)
+	router.POST("/api/airports/:code/amenities", authMiddleware, adminMiddleware, airportController.CreateAmenity)
+	router.PUT("/api/airports/:code/amenities/:id", authMiddleware, adminMiddleware, airportController.UpdateAmenity)
+	router.DELETE("/api/airports/:code/amenities/:id", authMiddleware, adminMiddleware, airportController.DeleteAmenity)
 
 	// Flight routes
 	router.POST("/api/flights/track", flightController.TrackFlight)
@@ -29,10 +49,27 @@ func RegisterRoutes(router *gin.Engine,
 	router.DELETE("/api/flights/:id", flightController.DeleteTrackedFlight)
 
 	// Location routes
-	router.POST("/api/location/update", locationController.UpdateLocation)
-	router.GET("/api/location/walk-time/:flightId", locationController.GetWalkTime)
+	router.POST("/api/location/update", authMiddleware, locationController.UpdateLocation)
+	router.GET("/api/location/walk-time/:flightId", authMiddleware, locationController.GetWalkTime)
+	router.GET("/api/location/history", authMiddleware, locationController.GetHistory)
+	router.POST("/api/location/history/settings", authMiddleware, locationController.UpdateHistorySettings)
+	router.DELETE("/api/location/history", authMiddleware, locationController.PurgeLocation)
+	router.GET("/api/location/mobility", authMiddleware, locationController.GetMobility)
+	router.POST("/api/location/mobility", authMiddleware, locationController.UpdateMobility)
+	router.DELETE("/api/location/pace", authMiddleware, locationController.ResetPace)
 
 	// Notification routes
-	router.GET("/api/notifications/:userId", notificationController.GetNotifications)
-	router.POST("/api/notifications/preferences", notificationController.UpdatePreferences)
+	router.GET("/api/notifications/unread-count", authMiddleware, notificationController.GetUnreadCount)
+	router.GET("/api/notifications/:userId", authMiddleware, notificationController.GetNotifications)
+	router.POST("/api/notifications/read-all", authMiddleware, notificationController.MarkAllRead)
+	router.POST("/api/notifications/:id/read", authMiddleware, notificationController.MarkRead)
+	router.DELETE("/api/notifications/:id", authMiddleware, notificationController.DeleteNotification)
+	router.POST("/api/notifications/preferences", authMiddleware, notificationController.UpdatePreferences)
+	router.POST("/api/notifications/digest", authMiddleware, notificationController.UpdateDigestPreferences)
+
+	// Realtime routes
+	router.GET("/ws", wsHandler.ServeWS)
+	router.GET("/api/events/stream", wsHandler.ServeSSE)
+	router.POST("/api/ws/ticket", authMiddleware, wsHandler.IssueTicket)
+	router.GET("/api/ws/stats", authMiddleware, wsHandler.GetStats)
 }
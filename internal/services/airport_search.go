@@ -0,0 +1,338 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/onoja123/travel-companion-backend/internal/database"
+	"github.com/onoja123/travel-companion-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// airportIndexTTL is how long the in-memory search index is served before
+// it is reloaded, so imports show up without a restart.
+const airportIndexTTL = 10 * time.Minute
+
+// AirportService answers type-ahead and nearby airport queries. Search
+// scores every airport in memory, since prefix and typo-tolerant matching
+// across several fields isn't something plain MongoDB indexes can rank;
+// the reference set is a few thousand rows.
+type AirportService struct {
+	MongoDB *database.MongoDB
+
+	mu       sync.RWMutex
+	index    []airportEntry
+	loadedAt time.Time
+	loading  bool // a reload is in progress; others keep serving index
+}
+
+func NewAirportService(db *database.MongoDB) *AirportService {
+	return &AirportService{
+		MongoDB: db,
+	}
+}
+
+type airportEntry struct {
+	summary models.AirportSummary
+	code    string   // folded IATA code
+	icao    string   // folded ICAO code
+	words   []string // folded codes and words of the name, city and country
+	rank    float64  // 0-10, from traffic or airport size
+}
+
+type airportMatch struct {
+	entry *airportEntry
+	score float64
+}
+
+// Search returns up to limit airports matching query, best first. Codes
+// match exactly or by prefix; otherwise every word of the query has to
+// match a code or the start of a word in the name, city or country,
+// allowing a typo or two in longer words. Matches are ranked by how well
+// they match, then traffic.
+func (s *AirportService) Search(ctx context.Context, query string, limit int) ([]models.AirportSummary, error) {
+	index, err := s.searchIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := strings.Fields(fold(query))
+	if len(terms) == 0 {
+		return []models.AirportSummary{}, nil
+	}
+	q := strings.Join(terms, " ")
+
+	var matches []airportMatch
+	for i := range index {
+		entry := &index[i]
+		if score := matchScore(entry, q, terms); score > 0 {
+			matches = append(matches, airportMatch{entry: entry, score: score + entry.rank})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].entry.code < matches[j].entry.code
+	})
+
+	results := make([]models.AirportSummary, 0, min(limit, len(matches)))
+	for _, m := range matches[:min(limit, len(matches))] {
+		results = append(results, m.entry.summary)
+	}
+	return results, nil
+}
+
+// Nearby returns up to limit airports within radiusKm of a point, nearest
+// first.
+func (s *AirportService) Nearby(ctx context.Context, lat, lon, radiusKm float64, limit int) ([]models.NearbyAirport, error) {
+	pipeline := []bson.M{
+		{"$geoNear": bson.M{
+			"near":          models.NewGeoPoint(lat, lon),
+			"distanceField": "distance",
+			"maxDistance":   radiusKm * 1000,
+			"spherical":     true,
+		}},
+		{"$limit": limit},
+		{"$project": bson.M{"runways": 0, "terminals": 0}},
+	}
+
+	cursor, err := s.MongoDB.Airports().Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find nearby airports: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		models.Airport `bson:",inline"`
+		Distance       float64 `bson:"distance"` // meters
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to find nearby airports: %w", err)
+	}
+
+	results := make([]models.NearbyAirport, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, models.NearbyAirport{
+			AirportSummary: summarizeAirport(row.Airport),
+			DistanceKm:     math.Round(row.Distance/100) / 10,
+		})
+	}
+	return results, nil
+}
+
+// searchIndex returns the cached index, reloading it once it is stale. The
+// reload runs outside the lock and one request at a time; meanwhile, and if
+// it fails, searches are served from the stale index.
+func (s *AirportService) searchIndex(ctx context.Context) ([]airportEntry, error) {
+	s.mu.RLock()
+	index, loadedAt := s.index, s.loadedAt
+	s.mu.RUnlock()
+	if index != nil && time.Since(loadedAt) < airportIndexTTL {
+		return index, nil
+	}
+
+	s.mu.Lock()
+	if s.index != nil && (s.loading || time.Since(s.loadedAt) < airportIndexTTL) {
+		index = s.index
+		s.mu.Unlock()
+		return index, nil
+	}
+	s.loading = true
+	s.mu.Unlock()
+
+	loaded, err := s.loadIndex(ctx)
+
+	s.mu.Lock()
+	s.loading = false
+	if err == nil {
+		s.index, s.loadedAt = loaded, time.Now()
+	}
+	index = s.index
+	s.mu.Unlock()
+
+	if err != nil {
+		if index == nil {
+			return nil, err
+		}
+		log.Printf("Serving stale airport search index: %v", err)
+	}
+	return index, nil
+}
+
+func (s *AirportService) loadIndex(ctx context.Context) ([]airportEntry, error) {
+	opts := options.Find().SetProjection(bson.M{"runways": 0, "terminals": 0})
+	cursor, err := s.MongoDB.Airports().Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load airports: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var airports []models.Airport
+	if err := cursor.All(ctx, &airports); err != nil {
+		return nil, fmt.Errorf("failed to load airports: %w", err)
+	}
+
+	index := make([]airportEntry, 0, len(airports))
+	for _, a := range airports {
+		index = append(index, newAirportEntry(a))
+	}
+	return index, nil
+}
+
+func newAirportEntry(a models.Airport) airportEntry {
+	words := strings.Fields(fold(strings.Join([]string{a.Code, a.ICAO, a.Name, a.City, a.CountryName}, " ")))
+
+	var rank float64
+	switch a.Type {
+	case "large_airport":
+		rank = 4
+	case "medium_airport":
+		rank = 2
+	}
+	// ~100M passengers a year, the busiest airports, scores 10
+	if a.Passengers > 0 {
+		rank = max(rank, min(10, 10*math.Log10(float64(a.Passengers))/8))
+	}
+
+	return airportEntry{
+		summary: summarizeAirport(a),
+		code:    fold(a.Code),
+		icao:    fold(a.ICAO),
+		words:   words,
+		rank:    rank,
+	}
+}
+
+// matchScore rates how well an airport matches a folded query: up to 100
+// for codes, up to 50 for words, or 0 for no match.
+func matchScore(e *airportEntry, q string, terms []string) float64 {
+	switch {
+	case q == e.code:
+		return 100
+	case q == e.icao:
+		return 90
+	case len(q) < len(e.code) && strings.HasPrefix(e.code, q):
+		return 60
+	case len(q) >= 3 && strings.HasPrefix(e.icao, q):
+		return 45
+	case q == fold(e.summary.Country):
+		return 30
+	}
+
+	var total float64
+	for _, term := range terms {
+		best := 0.0
+		for _, word := range e.words {
+			best = max(best, wordScore(term, word))
+		}
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+	return 50 * total / float64(len(terms))
+}
+
+// wordScore rates one query term against one word: 1 for the whole word,
+// 0.8 for a prefix, 0.5 for a whole word or prefix with a typo (two from
+// eight letters). Terms shorter than four letters don't get typos, or
+// almost everything would match.
+func wordScore(term, word string) float64 {
+	switch {
+	case term == word:
+		return 1
+	case strings.HasPrefix(word, term):
+		return 0.8
+	}
+
+	n := len([]rune(term))
+	if n < 4 {
+		return 0
+	}
+	allowed := 1
+	if n >= 8 {
+		allowed = 2
+	}
+
+	// Compare against the word's prefixes around the term's length, so
+	// "frankfrt" and "frnakf" both find Frankfurt.
+	w := []rune(word)
+	for size := n - allowed; size <= min(n+allowed, len(w)); size++ {
+		if editDistance(term, string(w[:size])) <= allowed {
+			return 0.5
+		}
+	}
+	return 0
+}
+
+// editDistance is the optimal string alignment distance: insertions,
+// deletions, substitutions and adjacent transpositions each cost one.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	prev2 := make([]int, len(t)+1)
+	prev := make([]int, len(t)+1)
+	curr := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		curr[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(t)]
+}
+
+// fold lowercases s, strips accents and turns punctuation into spaces, so
+// "São Paulo–Guarulhos" and "sao paulo guarulhos" compare equal.
+func fold(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, s)
+	if err != nil {
+		folded = s
+	}
+
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, folded)
+}
+
+func summarizeAirport(a models.Airport) models.AirportSummary {
+	return models.AirportSummary{
+		Code:        a.Code,
+		ICAO:        a.ICAO,
+		Name:        a.Name,
+		City:        a.City,
+		Country:     a.Country,
+		CountryName: a.CountryName,
+		Type:        a.Type,
+		Latitude:    a.Latitude,
+		Longitude:   a.Longitude,
+	}
+}
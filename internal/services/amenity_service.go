@@ -0,0 +1,478 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/database"
+	"github.com/onoja123/travel-companion-backend/internal/models"
+	"github.com/onoja123/travel-companion-backend/internal/terminal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrAirportNotFound  = errors.New("airport not found")
+	ErrTerminalNotFound = errors.New("terminal not found")
+	ErrAmenityNotFound  = errors.New("amenity not found")
+	ErrInvalidHours     = errors.New("invalid opening hours")
+)
+
+// Amenities near a gate are listed when they are signposted from it or
+// within these walks of it; farther ones are left out.
+const (
+	nearGateWalk  = 5 * time.Minute
+	concourseWalk = 10 * time.Minute
+	terminalWalk  = 20 * time.Minute
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// AmenityService maintains airport terminals, gates and amenities and
+// finds amenities near a gate.
+type AmenityService struct {
+	MongoDB   *database.MongoDB
+	Terminals *terminal.Registry
+}
+
+func NewAmenityService(db *database.MongoDB, terminals *terminal.Registry) *AmenityService {
+	return &AmenityService{
+		MongoDB:   db,
+		Terminals: terminals,
+	}
+}
+
+// AmenityFilter narrows an amenity listing. With NearGate, only airside
+// amenities close to that gate are listed, nearest first.
+type AmenityFilter struct {
+	NearGate string
+	Terminal string
+	Category string
+	OpenNow  bool
+}
+
+func (s *AmenityService) GetTerminals(ctx context.Context, airportCode string) ([]models.Terminal, error) {
+	airport, err := s.airport(ctx, airportCode)
+	if err != nil {
+		return nil, err
+	}
+	if airport.Terminals == nil {
+		return []models.Terminal{}, nil
+	}
+	return airport.Terminals, nil
+}
+
+// PutTerminal creates or replaces a terminal and its gates.
+func (s *AmenityService) PutTerminal(ctx context.Context, airportCode string, t models.Terminal) (*models.Terminal, error) {
+	if t.Gates == nil {
+		t.Gates = []models.Gate{}
+	}
+	for i := range t.Gates {
+		t.Gates[i].Label = strings.TrimSpace(t.Gates[i].Label)
+	}
+
+	replace := func() (bool, error) {
+		result, err := s.MongoDB.Airports().UpdateOne(ctx,
+			bson.M{"code": airportCode, "terminals.code": t.Code},
+			bson.M{"$set": bson.M{"terminals.$": t}},
+		)
+		if err != nil {
+			return false, fmt.Errorf("failed to update terminal: %w", err)
+		}
+		return result.MatchedCount > 0, nil
+	}
+
+	if ok, err := replace(); err != nil || ok {
+		return &t, err
+	}
+
+	result, err := s.MongoDB.Airports().UpdateOne(ctx,
+		bson.M{"code": airportCode, "terminals.code": bson.M{"$ne": t.Code}},
+		bson.M{"$push": bson.M{"terminals": t}},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add terminal: %w", err)
+	}
+	if result.MatchedCount > 0 {
+		return &t, nil
+	}
+
+	// Added concurrently, or the airport doesn't exist
+	if ok, err := replace(); err != nil || ok {
+		return &t, err
+	}
+	return nil, ErrAirportNotFound
+}
+
+func (s *AmenityService) DeleteTerminal(ctx context.Context, airportCode, terminalCode string) error {
+	result, err := s.MongoDB.Airports().UpdateOne(ctx,
+		bson.M{"code": airportCode, "terminals.code": terminalCode},
+		bson.M{"$pull": bson.M{"terminals": bson.M{"code": terminalCode}}},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete terminal: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrTerminalNotFound
+	}
+	return nil
+}
+
+func (s *AmenityService) CreateAmenity(ctx context.Context, airportCode string, req models.AmenityRequest) (*models.Amenity, error) {
+	if _, err := s.airport(ctx, airportCode); err != nil {
+		return nil, err
+	}
+
+	amenity, err := newAmenity(airportCode, req)
+	if err != nil {
+		return nil, err
+	}
+	amenity.ID = primitive.NewObjectID()
+	amenity.CreatedAt = amenity.UpdatedAt
+
+	if _, err := s.MongoDB.Amenities().InsertOne(ctx, amenity); err != nil {
+		return nil, fmt.Errorf("failed to create amenity: %w", err)
+	}
+	return amenity, nil
+}
+
+func (s *AmenityService) UpdateAmenity(ctx context.Context, airportCode string, id primitive.ObjectID, req models.AmenityRequest) (*models.Amenity, error) {
+	amenity, err := newAmenity(airportCode, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated models.Amenity
+	err = s.MongoDB.Amenities().FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "airport_code": airportCode},
+		bson.M{"$set": bson.M{
+			"terminal":      amenity.Terminal,
+			"name":          amenity.Name,
+			"category":      amenity.Category,
+			"description":   amenity.Description,
+			"airside":       amenity.Airside,
+			"near_gates":    amenity.NearGates,
+			"latitude":      amenity.Latitude,
+			"longitude":     amenity.Longitude,
+			"open_24_hours": amenity.Open24Hours,
+			"hours":         amenity.Hours,
+			"lounge":        amenity.Lounge,
+			"updated_at":    amenity.UpdatedAt,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAmenityNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update amenity: %w", err)
+	}
+	return &updated, nil
+}
+
+func (s *AmenityService) DeleteAmenity(ctx context.Context, airportCode string, id primitive.ObjectID) error {
+	result, err := s.MongoDB.Amenities().DeleteOne(ctx, bson.M{"_id": id, "airport_code": airportCode})
+	if err != nil {
+		return fmt.Errorf("failed to delete amenity: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrAmenityNotFound
+	}
+	return nil
+}
+
+// ListAmenities lists an airport's amenities. Near a gate they are ranked
+// by the walk from it through the terminal graph, when both the gate and
+// the amenity can be placed on it, and otherwise by whether the amenity is
+// signposted from the gate, then in the same concourse, then terminal.
+func (s *AmenityService) ListAmenities(ctx context.Context, airportCode string, filter AmenityFilter) ([]models.AmenityResult, error) {
+	airport, err := s.airport(ctx, airportCode)
+	if err != nil {
+		return nil, err
+	}
+
+	query := bson.M{"airport_code": airportCode}
+	if filter.Category != "" {
+		query["category"] = filter.Category
+	}
+	if filter.NearGate != "" {
+		query["airside"] = true
+	}
+
+	cursor, err := s.MongoDB.Amenities().Find(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list amenities: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var amenities []models.Amenity
+	if err := cursor.All(ctx, &amenities); err != nil {
+		return nil, fmt.Errorf("failed to list amenities: %w", err)
+	}
+
+	now := time.Now()
+	if loc, err := time.LoadLocation(airport.Timezone); err == nil {
+		now = now.In(loc)
+	}
+
+	gate := s.locateGate(airport, filter.NearGate)
+	terminalCode := filter.Terminal
+	if terminalCode == "" && gate != nil {
+		terminalCode = gate.terminal
+	}
+
+	type ranked struct {
+		result models.AmenityResult
+		tier   int
+		walk   float64
+	}
+	var list []ranked
+	for _, a := range amenities {
+		if terminalCode != "" && a.Terminal != "" && !strings.EqualFold(a.Terminal, terminalCode) {
+			continue
+		}
+
+		result := models.AmenityResult{Amenity: a}
+		if a.Open24Hours || len(a.Hours) > 0 {
+			open := a.Open24Hours || isOpen(a.Hours, now)
+			if filter.OpenNow && !open {
+				continue
+			}
+			result.OpenNow = &open
+		} else if filter.OpenNow {
+			continue
+		}
+
+		item := ranked{result: result, walk: math.Inf(1)}
+		if gate != nil {
+			item.tier = gate.tier(a)
+			if seconds, ok := gate.walk(a); ok {
+				item.walk = seconds
+				minutes := int(math.Ceil(seconds / 60))
+				item.result.WalkMinutes = &minutes
+				item.tier = walkTier(seconds)
+			}
+			if item.tier > 2 {
+				continue
+			}
+		}
+		list = append(list, item)
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].tier != list[j].tier {
+			return list[i].tier < list[j].tier
+		}
+		if list[i].walk != list[j].walk {
+			return list[i].walk < list[j].walk
+		}
+		return list[i].result.Name < list[j].result.Name
+	})
+
+	results := make([]models.AmenityResult, 0, len(list))
+	for _, item := range list {
+		results = append(results, item.result)
+	}
+	return results, nil
+}
+
+// gateLocation is where a gate is, as far as the airport's terminal list
+// and graph tell.
+type gateLocation struct {
+	label     string
+	concourse string
+	terminal  string
+	graph     *terminal.Graph
+	node      *terminal.Node
+}
+
+func (s *AmenityService) locateGate(airport *models.Airport, label string) *gateLocation {
+	if label == "" {
+		return nil
+	}
+
+	gate := &gateLocation{label: terminal.NormalizeGate(label)}
+	gate.concourse = concourseOf(gate.label)
+	for _, t := range airport.Terminals {
+		for _, g := range t.Gates {
+			if terminal.NormalizeGate(g.Label) == gate.label {
+				gate.terminal = t.Code
+				if g.Concourse != "" {
+					gate.concourse = strings.ToUpper(g.Concourse)
+				}
+			}
+		}
+	}
+
+	if s.Terminals != nil {
+		if graph, ok := s.Terminals.Get(airport.Code); ok {
+			if node, ok := graph.GateNode(gate.label, gate.terminal); ok {
+				gate.graph, gate.node = graph, node
+				if gate.terminal == "" {
+					gate.terminal = node.Terminal
+				}
+			}
+		}
+	}
+	return gate
+}
+
+// tier ranks an amenity without a routed walk: 0 when signposted from the
+// gate, 1 in the same concourse, 2 in the same terminal, 3 otherwise.
+func (g *gateLocation) tier(a models.Amenity) int {
+	for _, near := range a.NearGates {
+		if terminal.NormalizeGate(near) == g.label {
+			return 0
+		}
+	}
+	for _, near := range a.NearGates {
+		if g.concourse != "" && concourseOf(terminal.NormalizeGate(near)) == g.concourse {
+			return 1
+		}
+	}
+	if g.terminal != "" && strings.EqualFold(a.Terminal, g.terminal) {
+		return 2
+	}
+	return 3
+}
+
+// walk returns the seconds from the gate to the amenity through the
+// terminal graph, when it has coordinates and the gate is on a graph.
+func (g *gateLocation) walk(a models.Amenity) (float64, bool) {
+	if g.node == nil || (a.Latitude == 0 && a.Longitude == 0) {
+		return 0, false
+	}
+
+	nearest, offset := g.graph.NearestNode(a.Latitude, a.Longitude)
+	if nearest == nil {
+		return 0, false
+	}
+	route, err := g.graph.Route(g.node.ID, nearest.ID, terminal.WalkingSpeed)
+	if err != nil {
+		return 0, false
+	}
+	return route.Seconds + offset/terminal.WalkingSpeed, true
+}
+
+func walkTier(seconds float64) int {
+	walk := time.Duration(seconds) * time.Second
+	switch {
+	case walk <= nearGateWalk:
+		return 0
+	case walk <= concourseWalk:
+		return 1
+	case walk <= terminalWalk:
+		return 2
+	}
+	return 3
+}
+
+// concourseOf returns the letters a gate label starts with, "B" for "B32".
+func concourseOf(label string) string {
+	end := strings.IndexFunc(label, func(r rune) bool { return r < 'A' || r > 'Z' })
+	if end < 0 {
+		return label
+	}
+	return label[:end]
+}
+
+func (s *AmenityService) airport(ctx context.Context, code string) (*models.Airport, error) {
+	var airport models.Airport
+	err := s.MongoDB.Airports().FindOne(ctx, bson.M{"code": code}).Decode(&airport)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAirportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &airport, nil
+}
+
+func newAmenity(airportCode string, req models.AmenityRequest) (*models.Amenity, error) {
+	for _, h := range req.Hours {
+		if _, ok := parseClock(h.Open); !ok {
+			return nil, fmt.Errorf("%w: open %q is not HH:MM", ErrInvalidHours, h.Open)
+		}
+		if _, ok := parseClock(h.Close); !ok {
+			return nil, fmt.Errorf("%w: close %q is not HH:MM", ErrInvalidHours, h.Close)
+		}
+	}
+
+	lounge := req.Lounge
+	if req.Category != models.AmenityLounge {
+		lounge = nil
+	}
+
+	nearGates := make([]string, 0, len(req.NearGates))
+	for _, gate := range req.NearGates {
+		if gate = terminal.NormalizeGate(gate); gate != "" {
+			nearGates = append(nearGates, gate)
+		}
+	}
+
+	return &models.Amenity{
+		AirportCode: airportCode,
+		Terminal:    strings.TrimSpace(req.Terminal),
+		Name:        strings.TrimSpace(req.Name),
+		Category:    req.Category,
+		Description: req.Description,
+		Airside:     req.Airside,
+		NearGates:   nearGates,
+		Latitude:    req.Latitude,
+		Longitude:   req.Longitude,
+		Open24Hours: req.Open24Hours,
+		Hours:       req.Hours,
+		Lounge:      lounge,
+		UpdatedAt:   time.Now(),
+	}, nil
+}
+
+// isOpen reports whether any period covers now, including periods that
+// started the day before and run past midnight.
+func isOpen(hours []models.OpeningHours, now time.Time) bool {
+	minute := now.Hour()*60 + now.Minute()
+	today := now.Weekday()
+	yesterday := (today + 6) % 7
+
+	for _, h := range hours {
+		opens, _ := parseClock(h.Open)
+		closes, _ := parseClock(h.Close)
+		overnight := closes <= opens
+
+		for _, name := range h.Days {
+			day, ok := weekdays[name]
+			if !ok {
+				continue
+			}
+			if day == today && minute >= opens && (overnight || minute < closes) {
+				return true
+			}
+			if day == yesterday && overnight && minute < closes {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseClock parses "HH:MM" into minutes after midnight, allowing "24:00".
+func parseClock(s string) (int, bool) {
+	var h, m int
+	if n, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || n != 2 || len(s) != 5 {
+		return 0, false
+	}
+	if h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, false
+	}
+	return h*60 + m, true
+}
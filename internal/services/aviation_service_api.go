@@ -109,18 +109,21 @@ func (s *AviationService) getFromAviationStack(flightNumber, date string) (*mode
 	boardingTime := departureTime.Add(-40 * time.Minute)
 
 	flightStatus := &models.FlightStatus{
-		FlightKey:     fmt.Sprintf("%s_%s", flightNumber, date),
-		FlightNumber:  flightNumber,
-		AirlineCode:   flight.Airline.Iata,
-		Status:        s.mapStatus(flight.FlightStatus),
-		Gate:          flight.Departure.Gate,
-		Terminal:      flight.Departure.Terminal,
-		BoardingTime:  boardingTime,
-		DepartureTime: departureTime,
-		ArrivalTime:   arrivalTime,
-		DelayMinutes:  flight.Departure.Delay,
-		LastUpdated:   time.Now(),
-		RawData:       flight,
+		FlightKey:        fmt.Sprintf("%s_%s", flightNumber, date),
+		FlightNumber:     flightNumber,
+		AirlineCode:      flight.Airline.Iata,
+		DepartureAirport: flight.Departure.Iata,
+		ArrivalAirport:   flight.Arrival.Iata,
+		Status:           s.mapStatus(flight.FlightStatus),
+		Gate:             flight.Departure.Gate,
+		Terminal:         flight.Departure.Terminal,
+		BoardingTime:     boardingTime,
+		DepartureTime:    departureTime,
+		ArrivalTime:      arrivalTime,
+		DelayMinutes:     flight.Departure.Delay,
+		Timezone:         flight.Departure.Timezone,
+		LastUpdated:      time.Now(),
+		RawData:          flight,
 	}
 
 	return flightStatus, nil
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/database"
+	"github.com/onoja123/travel-companion-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DeviceService struct {
+	MongoDB *database.MongoDB
+}
+
+func NewDeviceService(db *database.MongoDB) *DeviceService {
+	return &DeviceService{
+		MongoDB: db,
+	}
+}
+
+func (s *DeviceService) RegisterDevice(ctx context.Context, userID primitive.ObjectID, req models.RegisterDeviceRequest) (*models.Device, error) {
+	// A token belongs to one installation. If it was registered under another
+	// user or device (e.g. after signing in as someone else), drop that entry
+	// so pushes are not delivered to the wrong account.
+	_, err := s.MongoDB.Devices().DeleteMany(ctx, bson.M{
+		"token": req.FCMToken,
+		"$nor":  bson.A{bson.M{"user_id": userID, "device_id": req.DeviceID}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to release token: %w", err)
+	}
+
+	now := time.Now()
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var device models.Device
+	err = s.MongoDB.Devices().FindOneAndUpdate(
+		ctx,
+		bson.M{"user_id": userID, "device_id": req.DeviceID},
+		bson.M{
+			"$set": bson.M{
+				"platform":    req.Platform,
+				"token":       req.FCMToken,
+				"app_version": req.AppVersion,
+				"last_seen":   now,
+				"updated_at":  now,
+			},
+			"$setOnInsert": bson.M{
+				"_id":        primitive.NewObjectID(),
+				"created_at": now,
+			},
+		},
+		opts,
+	).Decode(&device)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register device: %w", err)
+	}
+
+	return &device, nil
+}
+
+func (s *DeviceService) UnregisterDevice(ctx context.Context, userID primitive.ObjectID, deviceID string) error {
+	result, err := s.MongoDB.Devices().DeleteOne(ctx, bson.M{"user_id": userID, "device_id": deviceID})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("device not found")
+	}
+
+	return nil
+}
+
+func (s *DeviceService) GetUserDevices(ctx context.Context, userID primitive.ObjectID) ([]models.Device, error) {
+	cursor, err := s.MongoDB.Devices().Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	devices := []models.Device{}
+	if err := cursor.All(ctx, &devices); err != nil {
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+// GetDevicesForUsers loads the devices of many users in one query, keyed by user.
+func (s *DeviceService) GetDevicesForUsers(ctx context.Context, userIDs []primitive.ObjectID) (map[primitive.ObjectID][]models.Device, error) {
+	cursor, err := s.MongoDB.Devices().Find(ctx, bson.M{"user_id": bson.M{"$in": userIDs}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var devices []models.Device
+	if err := cursor.All(ctx, &devices); err != nil {
+		return nil, err
+	}
+
+	byUser := make(map[primitive.ObjectID][]models.Device)
+	for _, device := range devices {
+		byUser[device.UserID] = append(byUser[device.UserID], device)
+	}
+
+	return byUser, nil
+}
+
+// RemoveTokens deletes every device holding one of the given tokens. It is
+// used to prune registrations that FCM reports as unregistered or invalid.
+func (s *DeviceService) RemoveTokens(ctx context.Context, tokens []string) (int64, error) {
+	if len(tokens) == 0 {
+		return 0, nil
+	}
+
+	result, err := s.MongoDB.Devices().DeleteMany(ctx, bson.M{"token": bson.M{"$in": tokens}})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
+
+// legacyDeviceID names the device created for a token stored on the user
+// before devices existed. The app replaces it when it next registers.
+const legacyDeviceID = "legacy"
+
+// MigrateLegacyTokens moves the single fcm_token users were stored with into
+// the device registry and unsets it, so it is safe to run on every start.
+// Tokens already registered as a device are only unset.
+func (s *DeviceService) MigrateLegacyTokens(ctx context.Context) (int, error) {
+	cursor, err := s.MongoDB.Users().Find(
+		ctx,
+		bson.M{"fcm_token": bson.M{"$exists": true, "$ne": ""}},
+		options.Find().SetProjection(bson.M{"_id": 1, "fcm_token": 1}),
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []struct {
+		ID       primitive.ObjectID `bson:"_id"`
+		FCMToken string             `bson:"fcm_token"`
+	}
+	if err := cursor.All(ctx, &users); err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, user := range users {
+		registered, err := s.MongoDB.Devices().CountDocuments(ctx, bson.M{"token": user.FCMToken})
+		if err != nil {
+			return migrated, err
+		}
+
+		if registered == 0 {
+			now := time.Now()
+			_, err := s.MongoDB.Devices().UpdateOne(
+				ctx,
+				bson.M{"user_id": user.ID, "device_id": legacyDeviceID},
+				bson.M{"$setOnInsert": bson.M{
+					"_id":        primitive.NewObjectID(),
+					"platform":   "unknown",
+					"token":      user.FCMToken,
+					"last_seen":  now,
+					"created_at": now,
+					"updated_at": now,
+				}},
+				options.Update().SetUpsert(true),
+			)
+			if err != nil {
+				return migrated, fmt.Errorf("failed to migrate token for user %s: %w", user.ID.Hex(), err)
+			}
+			migrated++
+		}
+
+		if _, err := s.MongoDB.Users().UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$unset": bson.M{"fcm_token": ""}}); err != nil {
+			return migrated, err
+		}
+	}
+
+	return migrated, nil
+}
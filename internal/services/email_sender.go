@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/onoja123/travel-companion-backend/internal/config"
+	"github.com/onoja123/travel-companion-backend/internal/i18n"
+	"github.com/onoja123/travel-companion-backend/internal/models"
+)
+
+// SMTPSender delivers email digests through an SMTP relay.
+type SMTPSender struct {
+	Addr string
+	From string
+	Auth smtp.Auth
+}
+
+func NewSMTPSender(cfg config.SMTPConfig) *SMTPSender {
+	sender := &SMTPSender{
+		Addr: net.JoinHostPort(cfg.Host, cfg.Port),
+		From: cfg.From,
+	}
+	if cfg.Username != "" {
+		sender.Auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return sender
+}
+
+// Send emails msg to the user, with its title as the subject.
+func (s *SMTPSender) Send(ctx context.Context, user *models.User, msg *i18n.Message) error {
+	if user.Email == "" {
+		return fmt.Errorf("user %s has no email address", user.ID.Hex())
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := smtp.SendMail(s.Addr, s.Auth, s.From, []string{user.Email}, s.compose(user.Email, msg)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// compose builds a plain-text UTF-8 email. Header values are stripped of
+// line breaks and the subject is MIME-encoded.
+func (s *SMTPSender) compose(to string, msg *i18n.Message) []byte {
+	header := strings.NewReplacer("\r", "", "\n", "")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", header.Replace(s.From))
+	fmt.Fprintf(&b, "To: %s\r\n", header.Replace(to))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", header.Replace(msg.Title)))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
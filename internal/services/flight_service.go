@@ -5,19 +5,33 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/onoja123/travel-companion-backend/internal/database"
 	"github.com/onoja123/travel-companion-backend/internal/models"
+	"github.com/onoja123/travel-companion-backend/internal/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// boardingCountdownWindow is how long before boarding the poller starts
+// publishing countdown events.
+const boardingCountdownWindow = 60 * time.Minute
+
+// EventPublisher delivers realtime events to the live connections of an
+// audience: specific users and/or topic subscribers.
+type EventPublisher interface {
+	Publish(audience models.Audience, event models.Event)
+}
+
 type FlightService struct {
 	MongoDB         *database.MongoDB
 	Redis           *database.RedisClient
 	AviationService *AviationService
 	NotificationSvc *NotificationService
+	Events          EventPublisher
+	Weather         *WeatherService // optional; adds weather delay risk to statuses
 }
 
 func NewFlightService(
@@ -25,12 +39,14 @@ func NewFlightService(
 	redis *database.RedisClient,
 	aviationSvc *AviationService,
 	notifSvc *NotificationService,
+	events EventPublisher,
 ) *FlightService {
 	return &FlightService{
 		MongoDB:         db,
 		Redis:           redis,
 		AviationService: aviationSvc,
 		NotificationSvc: notifSvc,
+		Events:          events,
 	}
 }
 
@@ -103,7 +119,7 @@ func (s *FlightService) GetFlightStatus(ctx context.Context, flightNumber, date
 	// Try Redis cache first
 	cachedStatus, err := s.getFlightStatusFromCache(flightKey)
 	if err == nil && cachedStatus != nil {
-		return s.buildFlightStatusResponse(cachedStatus), nil
+		return s.withWeatherRisk(ctx, s.buildFlightStatusResponse(cachedStatus)), nil
 	}
 
 	// Try MongoDB
@@ -111,7 +127,7 @@ func (s *FlightService) GetFlightStatus(ctx context.Context, flightNumber, date
 	err = s.MongoDB.FlightStatus().FindOne(ctx, bson.M{"flight_key": flightKey}).Decode(&status)
 	if err == nil {
 		s.cacheFlightStatus(&status)
-		return s.buildFlightStatusResponse(&status), nil
+		return s.withWeatherRisk(ctx, s.buildFlightStatusResponse(&status)), nil
 	}
 
 	// Fetch from aviation API
@@ -124,7 +140,7 @@ func (s *FlightService) GetFlightStatus(ctx context.Context, flightNumber, date
 	s.cacheFlightStatus(status2)
 	s.MongoDB.FlightStatus().InsertOne(ctx, status2)
 
-	return s.buildFlightStatusResponse(status2), nil
+	return s.withWeatherRisk(ctx, s.buildFlightStatusResponse(status2)), nil
 }
 
 func (s *FlightService) DeleteTrackedFlight(ctx context.Context, flightID primitive.ObjectID, userID primitive.ObjectID) error {
@@ -195,6 +211,14 @@ func (s *FlightService) buildFlightStatusResponse(status *models.FlightStatus) *
 	}
 }
 
+// withWeatherRisk adds the weather delay risk at both ends of the flight.
+func (s *FlightService) withWeatherRisk(ctx context.Context, response *models.FlightStatusResponse) *models.FlightStatusResponse {
+	if s.Weather != nil {
+		response.WeatherRisk = s.Weather.FlightDelayRisk(ctx, &response.Flight)
+	}
+	return response
+}
+
 // Background polling service
 func (s *FlightService) StartPollingService(ctx context.Context) {
 	ticker := time.NewTicker(2 * time.Minute)
@@ -227,18 +251,26 @@ func (s *FlightService) pollAllActiveFlights(ctx context.Context) {
 		return
 	}
 
+	// Group followers by flight so each flight is fetched once per poll and
+	// its changes fan out to every follower in a single batch.
+	followers := make(map[string][]models.TrackedFlight)
 	for _, flight := range flights {
-		s.checkFlightUpdates(ctx, &flight)
+		key := fmt.Sprintf("%s_%s", flight.FlightNumber, flight.DepartureDate.Format("2006-01-02"))
+		followers[key] = append(followers[key], flight)
+	}
+
+	for flightKey, tracked := range followers {
+		s.checkFlightUpdates(ctx, flightKey, tracked)
 	}
 }
 
-func (s *FlightService) checkFlightUpdates(ctx context.Context, flight *models.TrackedFlight) {
+func (s *FlightService) checkFlightUpdates(ctx context.Context, flightKey string, tracked []models.TrackedFlight) {
+	flight := tracked[0]
 	dateStr := flight.DepartureDate.Format("2006-01-02")
 
 	// Get current status from cache/db
-	flightKey := fmt.Sprintf("%s_%s", flight.FlightNumber, dateStr)
 	var oldStatus models.FlightStatus
-	err := s.MongoDB.FlightStatus().FindOne(ctx, bson.M{"flight_key": flightKey}).Decode(&oldStatus)
+	s.MongoDB.FlightStatus().FindOne(ctx, bson.M{"flight_key": flightKey}).Decode(&oldStatus)
 
 	// Fetch latest status from API
 	newStatus, err := s.AviationService.GetFlightStatus(flight.FlightNumber, dateStr)
@@ -261,10 +293,135 @@ func (s *FlightService) checkFlightUpdates(ctx context.Context, flight *models.T
 		s.cacheFlightStatus(newStatus)
 
 		// Send notifications
-		s.NotificationSvc.HandleFlightChanges(ctx, flight.UserID, newStatus, changes)
+		userIDs := make([]primitive.ObjectID, 0, len(tracked))
+		for _, t := range tracked {
+			userIDs = append(userIDs, t.UserID)
+		}
+		s.NotificationSvc.HandleFlightChanges(ctx, userIDs, newStatus, changes)
+
+		log.Printf("✈️  Flight %s updated for %d follower(s): %v", flight.FlightNumber, len(userIDs), changes)
+	}
+
+	// Publish realtime events
+	s.publishFlightEvents(tracked, newStatus, changes)
+}
+
+// publishFlightEvents sends each detected change, plus a boarding countdown
+// close to boarding, to every follower and to subscribers of the flight and
+// trip topics. Each connection receives an event once.
+func (s *FlightService) publishFlightEvents(tracked []models.TrackedFlight, status *models.FlightStatus, changes map[string]interface{}) {
+	if s.Events == nil {
+		return
+	}
+
+	now := time.Now()
+	var events []models.Event
+
+	if change, ok := changes["gate"].(map[string]string); ok {
+		events = append(events, models.Event{
+			Type:      models.EventGateChanged,
+			FlightKey: status.FlightKey,
+			Data: models.GateChangedEvent{
+				OldGate:  change["old"],
+				NewGate:  change["new"],
+				Terminal: status.Terminal,
+			},
+			Timestamp: now,
+		})
+	}
+
+	if change, ok := changes["status"].(map[string]string); ok {
+		events = append(events, models.Event{
+			Type:      models.EventStatusChanged,
+			FlightKey: status.FlightKey,
+			Data: models.StatusChangedEvent{
+				OldStatus: change["old"],
+				NewStatus: change["new"],
+			},
+			Timestamp: now,
+		})
+	}
 
-		log.Printf("✈️  Flight %s updated: %v", flight.FlightNumber, changes)
+	if change, ok := changes["delay"].(map[string]int); ok {
+		events = append(events, models.Event{
+			Type:      models.EventDelayChanged,
+			FlightKey: status.FlightKey,
+			Data: models.DelayChangedEvent{
+				OldDelayMinutes: change["old"],
+				NewDelayMinutes: change["new"],
+				DepartureTime:   status.DepartureTime.Add(time.Duration(change["new"]) * time.Minute),
+			},
+			Timestamp: now,
+		})
 	}
+
+	if untilBoarding := status.BoardingTime.Sub(now); untilBoarding > 0 && untilBoarding <= boardingCountdownWindow {
+		response := s.buildFlightStatusResponse(status)
+		events = append(events, models.Event{
+			Type:      models.EventBoardingCountdown,
+			FlightKey: status.FlightKey,
+			Data: models.BoardingCountdownEvent{
+				Gate:             status.Gate,
+				BoardingMinutes:  response.TimeUntil.BoardingMinutes,
+				DepartureMinutes: response.TimeUntil.DepartureMinutes,
+				UrgencyLevel:     response.UrgencyLevel,
+			},
+			Timestamp: now,
+		})
+	}
+
+	if len(events) == 0 {
+		return
+	}
+
+	audience := models.Audience{Topics: []string{models.FlightTopic(status.FlightKey)}}
+	for _, t := range tracked {
+		audience.UserIDs = append(audience.UserIDs, t.UserID.Hex())
+		audience.Topics = append(audience.Topics, models.TripTopic(t.ID.Hex()))
+	}
+
+	for _, event := range events {
+		s.Events.Publish(audience, event)
+	}
+}
+
+// AuthorizeTopic checks a WebSocket subscription. Flight topics are public
+// but must name a well-formed flight key; trip topics are limited to the
+// user's own active tracked flights.
+func (s *FlightService) AuthorizeTopic(ctx context.Context, userID, topic string) error {
+	if key, ok := strings.CutPrefix(topic, "flight:"); ok {
+		i := strings.LastIndex(key, "_")
+		if i < 0 || !utils.IsValidFlightNumber(key[:i]) || !utils.IsValidDate(key[i+1:]) {
+			return fmt.Errorf("invalid flight key")
+		}
+		return nil
+	}
+
+	if tripID, ok := strings.CutPrefix(topic, "trip:"); ok {
+		tripObjID, err := primitive.ObjectIDFromHex(tripID)
+		if err != nil {
+			return fmt.Errorf("invalid trip ID")
+		}
+		userObjID, err := primitive.ObjectIDFromHex(userID)
+		if err != nil {
+			return fmt.Errorf("invalid user ID")
+		}
+
+		count, err := s.MongoDB.TrackedFlights().CountDocuments(ctx, bson.M{
+			"_id":       tripObjID,
+			"user_id":   userObjID,
+			"is_active": true,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to check trip: %w", err)
+		}
+		if count == 0 {
+			return fmt.Errorf("trip not found")
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown topic")
 }
 
 func (s *FlightService) detectChanges(old, new *models.FlightStatus) map[string]interface{} {
@@ -0,0 +1,398 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/models"
+	"github.com/onoja123/travel-companion-backend/internal/terminal"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	zoneOutside  = "outside"
+	zoneLandside = "landside"
+	zoneAirside  = "airside"
+
+	// zoneStateTTL forgets a user's zone after a day without fixes.
+	zoneStateTTL = 24 * time.Hour
+	// leaveNudgeLead sends the "leave for the airport" nudge this long
+	// before the leave-by time.
+	leaveNudgeLead = 15 * time.Minute
+	// leaveNudgeHorizon limits nudges to flights boarding within this window.
+	leaveNudgeHorizon = 6 * time.Hour
+
+	// A new zone is only confirmed after zoneConfirmFixes consecutive fixes
+	// in it, or two fixes zoneConfirmDwell apart, so GPS jitter at a fence
+	// edge does not flap between zones.
+	zoneConfirmFixes = 3
+	zoneConfirmDwell = time.Minute
+
+	// geofenceCacheTTL is how long a user's trips, their flight statuses
+	// and airports are reused across fixes.
+	geofenceCacheTTL = 2 * time.Minute
+)
+
+// GeofenceHandler reacts to a user arriving at, clearing security in and
+// leaving an airport, and to it being time to leave for one.
+type GeofenceHandler interface {
+	HandleGeofenceEvent(ctx context.Context, userID primitive.ObjectID, eventType string, event models.GeofenceEvent, flight *models.FlightStatus)
+	SendLeaveForAirport(ctx context.Context, userID primitive.ObjectID, flight *models.FlightStatus, estimate *models.WalkTimeResponse)
+}
+
+// zoneState is where a user was at their last confirmed fix, plus a
+// different zone the latest fixes are in but that is not yet confirmed.
+type zoneState struct {
+	Airport  string       `json:"airport,omitempty"`
+	Terminal string       `json:"terminal,omitempty"`
+	Zone     string       `json:"zone"`
+	Since    time.Time    `json:"since"`
+	Pending  *pendingZone `json:"pending,omitempty"`
+}
+
+type pendingZone struct {
+	Airport  string    `json:"airport,omitempty"`
+	Terminal string    `json:"terminal,omitempty"`
+	Zone     string    `json:"zone"`
+	Since    time.Time `json:"since"`
+	Fixes    int       `json:"fixes"`
+}
+
+// geofenceTrip is one of the user's active tracked flights with its status,
+// if known.
+type geofenceTrip struct {
+	Flight models.TrackedFlight `json:"flight"`
+	Status *models.FlightStatus `json:"status,omitempty"`
+}
+
+// geofenceCandidates is what fixes are checked against: the user's active
+// trips and the airports they depart from or arrive at.
+type geofenceCandidates struct {
+	Trips    []geofenceTrip   `json:"trips"`
+	Airports []models.Airport `json:"airports"`
+}
+
+func (c *geofenceCandidates) airport(code string) *models.Airport {
+	for i := range c.Airports {
+		if c.Airports[i].Code == code {
+			return &c.Airports[i]
+		}
+	}
+	return nil
+}
+
+// evaluateGeofences compares a fix against airport geofences, emits
+// entered/security/left events on transitions and, while the user is away
+// from the airport, nudges them when it is time to leave.
+func (s *LocationService) evaluateGeofences(ctx context.Context, loc *UserLocation) {
+	userID, err := primitive.ObjectIDFromHex(loc.UserID)
+	if err != nil {
+		return
+	}
+
+	candidates, err := s.geofenceCandidates(ctx, userID)
+	if err != nil {
+		log.Printf("Error fetching flights for geofencing: %v", err)
+		return
+	}
+
+	observed := s.locateUser(loc, candidates)
+
+	previous, err := s.zoneState(ctx, loc.UserID)
+	if err != nil {
+		log.Printf("Error reading zone state for %s: %v", loc.UserID, err)
+		return
+	}
+
+	current, changed := advanceZone(previous, observed, time.Now())
+	if changed {
+		s.emitTransitions(ctx, userID, previous, current, candidates)
+
+		// Landside straight to airside is one trip through security
+		if previous.Zone == zoneLandside && current.Zone == zoneAirside && previous.Airport == current.Airport && !previous.Since.IsZero() {
+			s.recordDwell(ctx, current.Airport, s.checkpointFor(loc, current), current.Since.Sub(previous.Since), current.Since)
+		}
+	}
+
+	if err := s.saveZoneState(ctx, loc.UserID, current); err != nil {
+		log.Printf("Error saving zone state for %s: %v", loc.UserID, err)
+	}
+
+	if current.Zone == zoneOutside {
+		s.checkLeaveNudges(ctx, userID, loc, candidates)
+	}
+}
+
+// advanceZone moves a user's zone state on by one fix observed in zone
+// observed. A different zone first becomes pending and replaces the current
+// one, backdated to when it was first seen, once confirmed; changed reports
+// that. A fix back in the current zone discards anything pending.
+func advanceZone(previous, observed zoneState, now time.Time) (next zoneState, changed bool) {
+	next = previous
+	if observed.Airport == previous.Airport && observed.Zone == previous.Zone {
+		next.Pending = nil
+		return next, false
+	}
+
+	pending := previous.Pending
+	if pending == nil || pending.Airport != observed.Airport || pending.Zone != observed.Zone {
+		pending = &pendingZone{Airport: observed.Airport, Zone: observed.Zone, Since: now}
+	} else {
+		pending = &pendingZone{Airport: pending.Airport, Zone: pending.Zone, Since: pending.Since, Fixes: pending.Fixes}
+	}
+	pending.Terminal = observed.Terminal
+	pending.Fixes++
+
+	if pending.Fixes < zoneConfirmFixes && (pending.Fixes < 2 || now.Sub(pending.Since) < zoneConfirmDwell) {
+		next.Pending = pending
+		return next, false
+	}
+
+	return zoneState{Airport: pending.Airport, Terminal: pending.Terminal, Zone: pending.Zone, Since: pending.Since}, true
+}
+
+// locateUser places a fix using the polygon fences first, then a radius
+// around the airports of the user's tracked flights that have no fences.
+func (s *LocationService) locateUser(loc *UserLocation, candidates *geofenceCandidates) zoneState {
+	if p, ok := s.Terminals.Locate(loc.Latitude, loc.Longitude); ok {
+		state := zoneState{Airport: p.Airport, Terminal: p.Terminal, Zone: zoneLandside}
+		if p.Airside {
+			state.Zone = zoneAirside
+		}
+		return state
+	}
+
+	for _, a := range candidates.Airports {
+		if s.Terminals.HasFences(a.Code) {
+			continue
+		}
+		if terminal.Distance(loc.Latitude, loc.Longitude, a.Latitude, a.Longitude) <= airportRadiusMeters {
+			return zoneState{Airport: a.Code, Zone: zoneLandside}
+		}
+	}
+
+	return zoneState{Zone: zoneOutside}
+}
+
+func (s *LocationService) emitTransitions(ctx context.Context, userID primitive.ObjectID, previous, current zoneState, candidates *geofenceCandidates) {
+	if previous.Airport != "" && previous.Airport != current.Airport {
+		s.emitGeofenceEvent(ctx, userID, models.EventAirportLeft, models.GeofenceEvent{
+			AirportCode: previous.Airport,
+			Terminal:    previous.Terminal,
+			Zone:        zoneOutside,
+		}, candidates)
+	}
+
+	if current.Airport == "" {
+		return
+	}
+
+	if previous.Airport != current.Airport {
+		event := models.GeofenceEvent{
+			AirportCode: current.Airport,
+			Terminal:    current.Terminal,
+			Zone:        current.Zone,
+		}
+		if current.Zone == zoneLandside {
+			if wait, err := s.GetSecurityWaitTime(ctx, current.Airport); err == nil {
+				event.SecurityWaitMinutes = wait.CurrentWaitTime
+			}
+		}
+		s.emitGeofenceEvent(ctx, userID, models.EventAirportEntered, event, candidates)
+	}
+
+	if current.Zone == zoneAirside && (previous.Airport != current.Airport || previous.Zone != zoneAirside) {
+		s.emitGeofenceEvent(ctx, userID, models.EventSecurityPassed, models.GeofenceEvent{
+			AirportCode: current.Airport,
+			Terminal:    current.Terminal,
+			Zone:        zoneAirside,
+		}, candidates)
+	}
+}
+
+// emitGeofenceEvent publishes the event to the user's live connections and
+// passes it to the notification handler with the flight departing from
+// that airport, if any. The handler sees each event type once per flight,
+// however often the user crosses the fence.
+func (s *LocationService) emitGeofenceEvent(ctx context.Context, userID primitive.ObjectID, eventType string, event models.GeofenceEvent, candidates *geofenceCandidates) {
+	var flight *models.FlightStatus
+	for _, trip := range candidates.Trips {
+		if trip.Flight.DepartureAirport == event.AirportCode {
+			flight = trip.Status
+			break
+		}
+	}
+
+	if s.Events != nil {
+		e := models.Event{Type: eventType, Data: event, Timestamp: time.Now()}
+		if flight != nil {
+			e.FlightKey = flight.FlightKey
+		}
+		s.Events.Publish(models.Audience{UserIDs: []string{userID.Hex()}}, e)
+	}
+
+	if s.Geofences != nil && flight != nil {
+		key := fmt.Sprintf("nudge:geofence:%s:%s:%s", userID.Hex(), flight.FlightKey, eventType)
+		if first, err := s.Redis.Client.SetNX(ctx, key, 1, zoneStateTTL).Result(); err == nil && first {
+			s.Geofences.HandleGeofenceEvent(ctx, userID, eventType, event, flight)
+		}
+	}
+
+	log.Printf("📍 %s %s %s", userID.Hex(), eventType, event.AirportCode)
+}
+
+// checkLeaveNudges sends one "leave for the airport" nudge per flight once
+// the leave-by time is near. It only runs while the user is outside every
+// airport, so the nudge is suppressed once they have arrived.
+func (s *LocationService) checkLeaveNudges(ctx context.Context, userID primitive.ObjectID, loc *UserLocation, candidates *geofenceCandidates) {
+	if s.Geofences == nil {
+		return
+	}
+
+	var w *walker
+	for i := range candidates.Trips {
+		flight := &candidates.Trips[i].Flight
+		status := candidates.Trips[i].Status
+		if status == nil || status.BoardingTime.IsZero() || time.Until(status.BoardingTime) > leaveNudgeHorizon {
+			continue
+		}
+
+		airport := candidates.airport(flight.DepartureAirport)
+		if airport == nil {
+			continue
+		}
+
+		if w == nil {
+			profile := s.walkerFor(ctx, userID)
+			w = &profile
+		}
+		estimate, err := s.timeToGate(ctx, loc, airport, status, *w)
+		if err != nil || estimate.Position != positionOffAirport || estimate.LeaveBy == nil {
+			continue
+		}
+		if time.Until(*estimate.LeaveBy) > leaveNudgeLead {
+			continue
+		}
+
+		key := fmt.Sprintf("nudge:leave:%s:%s", userID.Hex(), flight.ID.Hex())
+		first, err := s.Redis.Client.SetNX(ctx, key, 1, leaveNudgeHorizon).Result()
+		if err != nil || !first {
+			continue
+		}
+
+		estimate.FlightID = flight.ID.Hex()
+		s.Geofences.SendLeaveForAirport(ctx, userID, status, estimate)
+	}
+}
+
+// geofenceCandidates loads the user's active trips, their statuses and
+// airports, cached briefly since fixes arrive every few seconds.
+func (s *LocationService) geofenceCandidates(ctx context.Context, userID primitive.ObjectID) (*geofenceCandidates, error) {
+	key := geofenceCacheKey(userID.Hex())
+
+	var candidates geofenceCandidates
+	if data, err := s.Redis.Client.Get(ctx, key).Bytes(); err == nil {
+		if err := json.Unmarshal(data, &candidates); err == nil {
+			return &candidates, nil
+		}
+	}
+
+	flights, err := s.activeFlights(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	for i := range flights {
+		trip := geofenceTrip{Flight: flights[i]}
+		if status, err := s.flightStatus(ctx, &flights[i]); err == nil {
+			trip.Status = status
+		}
+		candidates.Trips = append(candidates.Trips, trip)
+
+		for _, code := range []string{flights[i].DepartureAirport, flights[i].ArrivalAirport} {
+			if code != "" && !slices.Contains(codes, code) {
+				codes = append(codes, code)
+			}
+		}
+	}
+
+	if len(codes) > 0 {
+		opts := options.Find().SetProjection(bson.M{"runways": 0, "terminals": 0})
+		cursor, err := s.MongoDB.Airports().Find(ctx, bson.M{"code": bson.M{"$in": codes}}, opts)
+		if err != nil {
+			return nil, err
+		}
+		if err := cursor.All(ctx, &candidates.Airports); err != nil {
+			return nil, err
+		}
+	}
+
+	if data, err := json.Marshal(candidates); err == nil {
+		s.Redis.Client.Set(ctx, key, data, geofenceCacheTTL)
+	}
+	return &candidates, nil
+}
+
+func (s *LocationService) activeFlights(ctx context.Context, userID primitive.ObjectID) ([]models.TrackedFlight, error) {
+	cursor, err := s.MongoDB.TrackedFlights().Find(ctx, bson.M{"user_id": userID, "is_active": true})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var flights []models.TrackedFlight
+	if err := cursor.All(ctx, &flights); err != nil {
+		return nil, err
+	}
+	return flights, nil
+}
+
+func (s *LocationService) flightStatus(ctx context.Context, flight *models.TrackedFlight) (*models.FlightStatus, error) {
+	flightKey := fmt.Sprintf("%s_%s", flight.FlightNumber, flight.DepartureDate.Format("2006-01-02"))
+
+	var status models.FlightStatus
+	if err := s.MongoDB.FlightStatus().FindOne(ctx, bson.M{"flight_key": flightKey}).Decode(&status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+func (s *LocationService) zoneState(ctx context.Context, userID string) (zoneState, error) {
+	data, err := s.Redis.Client.Get(ctx, zoneKey(userID)).Bytes()
+	if err == redis.Nil {
+		return zoneState{Zone: zoneOutside}, nil
+	}
+	if err != nil {
+		return zoneState{}, err
+	}
+
+	var state zoneState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return zoneState{Zone: zoneOutside}, nil
+	}
+	return state, nil
+}
+
+func (s *LocationService) saveZoneState(ctx context.Context, userID string, state zoneState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return s.Redis.Client.Set(ctx, zoneKey(userID), data, zoneStateTTL).Err()
+}
+
+func zoneKey(userID string) string {
+	return fmt.Sprintf("user:zone:%s", userID)
+}
+
+func geofenceCacheKey(userID string) string {
+	return fmt.Sprintf("user:geofence:%s", userID)
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdvanceZoneHysteresis(t *testing.T) {
+	start := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	outside := zoneState{Zone: zoneOutside, Since: start.Add(-time.Hour)}
+	landside := zoneState{Airport: "JFK", Terminal: "T4", Zone: zoneLandside}
+
+	tests := []struct {
+		name    string
+		fixes   []zoneState
+		gap     time.Duration
+		changed []bool
+	}{
+		{
+			name:    "jitter across the fence",
+			fixes:   []zoneState{landside, outside, landside, outside, landside},
+			gap:     5 * time.Second,
+			changed: []bool{false, false, false, false, false},
+		},
+		{
+			name:    "consecutive fixes",
+			fixes:   []zoneState{landside, landside, landside, landside},
+			gap:     5 * time.Second,
+			changed: []bool{false, false, true, false},
+		},
+		{
+			name:    "dwell",
+			fixes:   []zoneState{landside, landside},
+			gap:     zoneConfirmDwell,
+			changed: []bool{false, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := outside
+			now := start
+			for i, fix := range tt.fixes {
+				var changed bool
+				state, changed = advanceZone(state, fix, now)
+				if changed != tt.changed[i] {
+					t.Fatalf("fix %d: changed = %v, want %v", i, changed, tt.changed[i])
+				}
+				if changed && (state.Airport != "JFK" || state.Zone != zoneLandside || !state.Since.Equal(start) || state.Pending != nil) {
+					t.Fatalf("fix %d: confirmed %+v, want landside at JFK since the first fix", i, state)
+				}
+				now = now.Add(tt.gap)
+			}
+		})
+	}
+}
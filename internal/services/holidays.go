@@ -0,0 +1,103 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Holiday changes how busy an airport is expected to be on a date. Like
+// forecasts the day as another weekday (the Wednesday before Thanksgiving
+// runs like a Friday) and Factor scales the result.
+//
+// The calendar file looks like:
+//
+//	{"holidays": [
+//	  {"date": "2026-11-25", "name": "Thanksgiving Eve", "like": "friday", "factor": 1.3},
+//	  {"date": "2026-12-24", "name": "Christmas Eve", "airports": ["JFK", "LGA"], "factor": 0.8}
+//	]}
+//
+// Airports limits a holiday to those airports; without it, it applies
+// everywhere. Dates are in each airport's local time.
+type Holiday struct {
+	Date     string   `json:"date"` // YYYY-MM-DD
+	Name     string   `json:"name"`
+	Airports []string `json:"airports,omitempty"`
+	Like     string   `json:"like,omitempty"`   // weekday name
+	Factor   float64  `json:"factor,omitempty"` // default 1
+}
+
+// HolidayCalendar looks up holidays by local date.
+type HolidayCalendar struct {
+	byDate map[string][]Holiday
+}
+
+// LoadHolidays reads a holiday calendar. A missing file yields an empty
+// calendar.
+func LoadHolidays(path string) (*HolidayCalendar, error) {
+	cal := &HolidayCalendar{byDate: make(map[string][]Holiday)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cal, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read holiday calendar: %w", err)
+	}
+
+	var file struct {
+		Holidays []Holiday `json:"holidays"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid holiday calendar %s: %w", path, err)
+	}
+
+	for _, h := range file.Holidays {
+		if _, err := time.Parse("2006-01-02", h.Date); err != nil {
+			return nil, fmt.Errorf("holiday %q: invalid date %q", h.Name, h.Date)
+		}
+		if h.Like != "" {
+			if _, ok := parseWeekday(h.Like); !ok {
+				return nil, fmt.Errorf("holiday %q: invalid weekday %q", h.Name, h.Like)
+			}
+		}
+		if h.Factor <= 0 {
+			h.Factor = 1
+		}
+		for i, code := range h.Airports {
+			h.Airports[i] = strings.ToUpper(code)
+		}
+		cal.byDate[h.Date] = append(cal.byDate[h.Date], h)
+	}
+
+	log.Printf("Loaded %d holidays", len(file.Holidays))
+	return cal, nil
+}
+
+// Lookup returns the holiday at airport on local's date, if any.
+func (c *HolidayCalendar) Lookup(airport string, local time.Time) (Holiday, bool) {
+	if c == nil {
+		return Holiday{}, false
+	}
+
+	airport = strings.ToUpper(airport)
+	for _, h := range c.byDate[local.Format("2006-01-02")] {
+		if len(h.Airports) == 0 || slices.Contains(h.Airports, airport) {
+			return h, true
+		}
+	}
+	return Holiday{}, false
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), name) {
+			return d, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/models"
+	"github.com/onoja123/travel-companion-backend/internal/terminal"
+)
+
+// Where the user is relative to the departure airport
+const (
+	positionOffAirport = "off_airport"
+	positionLandside   = "landside"
+	positionAirside    = "airside"
+)
+
+const (
+	// airportRadiusMeters is how close to the airport reference point a
+	// user must be to count as at the airport when it has no terminal graph.
+	airportRadiusMeters = 2000
+	// terminalRadiusMeters is how close to a terminal graph node a user
+	// must be to count as inside the terminal.
+	terminalRadiusMeters = 300
+	// defaultTerminalWalk is assumed from the curb to the gate when the
+	// airport has no terminal graph.
+	defaultTerminalWalk = 10 * time.Minute
+	// defaultBoardingLead estimates boarding from departure when the
+	// airline has not published a boarding time.
+	defaultBoardingLead = 30 * time.Minute
+)
+
+// timeToGate fills in the leave-by estimate: travel to the airport when
+// off-airport, the security queue unless already airside, and the walk to
+// the gate at w's pace, compared against boarding.
+func (s *LocationService) timeToGate(ctx context.Context, userLoc *UserLocation, airport *models.Airport, status *models.FlightStatus, w walker) (*models.WalkTimeResponse, error) {
+	graph, hasGraph := s.Terminals.Get(airport.Code)
+	position := s.position(userLoc, airport, graph)
+
+	resp := &models.WalkTimeResponse{Gate: status.Gate, Position: position}
+
+	// Travel to the airport
+	var travel time.Duration
+	if position == positionOffAirport {
+		d, err := s.TravelTimes.TravelTime(ctx, userLoc.Latitude, userLoc.Longitude, airport.Latitude, airport.Longitude)
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate travel time: %w", err)
+		}
+		travel = d
+	}
+
+	// Walk to the gate, from the terminal entrance if not there yet
+	lat, lon := userLoc.Latitude, userLoc.Longitude
+	if position == positionOffAirport && hasGraph {
+		if entrance := nearestOfType(graph, lat, lon, terminal.NodeEntrance); entrance != nil {
+			lat, lon = entrance.Latitude, entrance.Longitude
+		}
+	}
+	walk, routed := s.walkToGate(lat, lon, graph, status, w)
+	switch {
+	case routed:
+		resp.Method = "terminal_graph"
+	case position == positionOffAirport:
+		walk.seconds = defaultTerminalWalk.Seconds() * terminal.WalkingSpeed / w.speed
+		walk.distance = 0
+		resp.Method = "estimate"
+	default:
+		walk.distance = terminal.Distance(lat, lon, airport.Latitude, airport.Longitude)
+		walk.seconds = walk.distance / w.speed
+		resp.Method = "straight_line"
+	}
+	resp.DistanceMeters = walk.distance
+	resp.WalkTimeMinutes = int(math.Ceil(walk.seconds / 60))
+	resp.WalkingSpeed = math.Round(w.speed*100) / 100
+	resp.Route = walk.steps
+
+	// Security queue: the current one when at the airport, the forecast
+	// for the arrival time when still on the way
+	switch position {
+	case positionLandside:
+		if wait, err := s.GetSecurityWaitTime(ctx, airport.Code); err == nil {
+			resp.SecurityWaitMinutes = wait.CurrentWaitTime
+		} else {
+			resp.SecurityWaitMinutes = airport.SecurityWaitAvg
+		}
+	case positionOffAirport:
+		if forecast, err := s.forecastWait(ctx, airport, time.Now().Add(travel)); err == nil {
+			resp.SecurityWaitMinutes = forecast.WaitMinutes
+		} else {
+			resp.SecurityWaitMinutes = airport.SecurityWaitAvg
+		}
+	}
+
+	resp.TravelMinutes = int(math.Ceil(travel.Minutes()))
+	resp.TotalMinutes = resp.TravelMinutes + resp.SecurityWaitMinutes + resp.WalkTimeMinutes
+
+	boarding := status.BoardingTime
+	if boarding.IsZero() && !status.DepartureTime.IsZero() {
+		boarding = status.DepartureTime.Add(-defaultBoardingLead)
+	}
+	if boarding.IsZero() {
+		resp.UrgencyLevel = "calm"
+		resp.RecommendedAction = "Boarding time not yet available"
+		return resp, nil
+	}
+
+	leaveBy := boarding.Add(-time.Duration(resp.TotalMinutes) * time.Minute)
+	resp.LeaveBy = &leaveBy
+	resp.UrgencyLevel, resp.RecommendedAction = urgency(position, resp.TotalMinutes, int(time.Until(boarding).Minutes()), w.margin, leaveBy, status.Timezone)
+
+	return resp, nil
+}
+
+// position classifies the user. Geofences decide when the airport has
+// them; otherwise, inside a terminal graph's footprint the nearest node
+// says whether they are past security, and without a graph anyone near the
+// airport is assumed landside.
+func (s *LocationService) position(userLoc *UserLocation, airport *models.Airport, graph *terminal.Graph) string {
+	if s.Terminals.HasFences(airport.Code) {
+		p, ok := s.Terminals.Locate(userLoc.Latitude, userLoc.Longitude)
+		switch {
+		case !ok || p.Airport != airport.Code:
+			return positionOffAirport
+		case p.Airside:
+			return positionAirside
+		}
+		return positionLandside
+	}
+
+	if graph != nil {
+		if node, d := graph.NearestNode(userLoc.Latitude, userLoc.Longitude); node != nil && d <= terminalRadiusMeters {
+			if node.Airside {
+				return positionAirside
+			}
+			return positionLandside
+		}
+	}
+
+	if terminal.Distance(userLoc.Latitude, userLoc.Longitude, airport.Latitude, airport.Longitude) <= airportRadiusMeters {
+		return positionLandside
+	}
+	return positionOffAirport
+}
+
+// urgency grades the slack before boarding. margin widens the urgent and
+// moderate bands for travelers who need more time than the estimate shows.
+func urgency(position string, totalMinutes, minutesUntilBoarding, margin int, leaveBy time.Time, timezone string) (level, action string) {
+	loc := time.UTC
+	if timezone != "" {
+		if l, err := time.LoadLocation(timezone); err == nil {
+			loc = l
+		}
+	}
+	at := leaveBy.In(loc).Format("15:04")
+
+	next := map[string]string{
+		positionOffAirport: "Leave for the airport",
+		positionLandside:   "Head to security",
+		positionAirside:    "Head to gate",
+	}[position]
+
+	switch {
+	case totalMinutes > minutesUntilBoarding:
+		return "critical", next + " immediately!"
+	case totalMinutes+10+margin > minutesUntilBoarding:
+		return "urgent", next + " now"
+	case totalMinutes+20+margin > minutesUntilBoarding:
+		return "moderate", next + " by " + at
+	}
+
+	if position == positionOffAirport {
+		return "calm", "Leave for the airport by " + at
+	}
+	return "calm", "You have plenty of time"
+}
+
+func nearestOfType(graph *terminal.Graph, lat, lon float64, nodeType string) *terminal.Node {
+	var nearest *terminal.Node
+	best := math.Inf(1)
+	for _, n := range graph.Nodes() {
+		if n.Type != nodeType {
+			continue
+		}
+		if d := terminal.Distance(lat, lon, n.Latitude, n.Longitude); d < best {
+			nearest, best = n, d
+		}
+	}
+	return nearest
+}
+
+// gateWalk is the on-foot part of the trip.
+type gateWalk struct {
+	distance float64
+	seconds  float64
+	steps    []models.RouteStep
+}
+
+// walkToGate routes from a point to the departure gate through the terminal
+// graph, from the nearest node, at w's pace and around the edges it avoids.
+// It reports false when there is no graph, no known gate or no route.
+func (s *LocationService) walkToGate(lat, lon float64, graph *terminal.Graph, status *models.FlightStatus, w walker) (gateWalk, bool) {
+	if graph == nil || status.Gate == "" {
+		return gateWalk{}, false
+	}
+
+	gate, ok := graph.GateNode(status.Gate, status.Terminal)
+	if !ok {
+		return gateWalk{}, false
+	}
+
+	start, approach := graph.NearestNode(lat, lon)
+	route, err := graph.Route(start.ID, gate.ID, w.speed, w.avoid...)
+	if err != nil {
+		log.Printf("Terminal routing failed at %s: %v", graph.Airport, err)
+		return gateWalk{}, false
+	}
+
+	return gateWalk{
+		distance: approach + route.DistanceMeters,
+		seconds:  approach/w.speed + route.Seconds,
+		steps:    routeSteps(route, w.speed),
+	}, true
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// defaultHistoryRetention is used when no retention is configured.
+	defaultHistoryRetention = 30 * 24 * time.Hour
+	// historyInterval keeps at most one point per user per interval.
+	historyInterval = time.Minute
+	// coarseDecimals rounds coordinates kept beyond a trip to about 1 km.
+	coarseDecimals = 2
+	// A trip is active from tripLead before departure until tripTail after
+	// arrival; without an arrival time, until tripFallback after the
+	// departure date.
+	tripLead     = 24 * time.Hour
+	tripTail     = 2 * time.Hour
+	tripFallback = 48 * time.Hour
+	// historyMaxPoints caps a single history query.
+	historyMaxPoints = 1000
+)
+
+// recordHistory stores a fix for users who opted in. Fixes taken during an
+// active trip keep full precision until the trip ends; the rest are stored
+// coarse straight away.
+func (s *LocationService) recordHistory(ctx context.Context, loc *UserLocation) {
+	userID, err := primitive.ObjectIDFromHex(loc.UserID)
+	if err != nil {
+		return
+	}
+
+	throttle := fmt.Sprintf("user:history:%s", loc.UserID)
+	first, err := s.Redis.Client.SetNX(ctx, throttle, 1, historyInterval).Result()
+	if err != nil || !first {
+		return
+	}
+
+	settings, err := s.historySettings(ctx, userID)
+	if err != nil || !settings.Enabled {
+		return
+	}
+
+	point := models.LocationPoint{
+		UserID:     userID,
+		Latitude:   coarsen(loc.Latitude),
+		Longitude:  coarsen(loc.Longitude),
+		RecordedAt: loc.Timestamp,
+		ExpiresAt:  loc.Timestamp.Add(s.retention(settings)),
+	}
+
+	if flight, end, ok := s.activeTrip(ctx, userID, loc.Timestamp); ok {
+		point.Latitude, point.Longitude = loc.Latitude, loc.Longitude
+		point.Precise = true
+		point.FlightID = &flight.ID
+		point.CoarsenAt = &end
+	}
+
+	if _, err := s.MongoDB.LocationHistory().InsertOne(ctx, point); err != nil {
+		log.Printf("Error saving location history for %s: %v", loc.UserID, err)
+	}
+}
+
+// activeTrip returns the tracked flight whose trip window contains at, and
+// when that window closes.
+func (s *LocationService) activeTrip(ctx context.Context, userID primitive.ObjectID, at time.Time) (*models.TrackedFlight, time.Time, bool) {
+	flights, err := s.activeFlights(ctx, userID)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+
+	for i := range flights {
+		flight := &flights[i]
+
+		departure := flight.DepartureDate
+		end := flight.DepartureDate.Add(tripFallback)
+		if status, err := s.flightStatus(ctx, flight); err == nil {
+			if !status.DepartureTime.IsZero() {
+				departure = status.DepartureTime
+			}
+			if !status.ArrivalTime.IsZero() {
+				end = status.ArrivalTime.Add(tripTail)
+			}
+		}
+
+		if !at.Before(departure.Add(-tripLead)) && at.Before(end) {
+			return flight, end, true
+		}
+	}
+	return nil, time.Time{}, false
+}
+
+func (s *LocationService) historySettings(ctx context.Context, userID primitive.ObjectID) (models.HistorySettings, error) {
+	var user models.User
+	opts := options.FindOne().SetProjection(bson.M{"location_history": 1})
+	if err := s.MongoDB.Users().FindOne(ctx, bson.M{"_id": userID}, opts).Decode(&user); err != nil {
+		return models.HistorySettings{}, err
+	}
+	return user.History, nil
+}
+
+// retention is the user's chosen retention, capped at the server's.
+func (s *LocationService) retention(settings models.HistorySettings) time.Duration {
+	limit := s.HistoryRetention
+	if limit <= 0 {
+		limit = defaultHistoryRetention
+	}
+	if settings.RetentionDays > 0 {
+		if d := time.Duration(settings.RetentionDays) * 24 * time.Hour; d < limit {
+			return d
+		}
+	}
+	return limit
+}
+
+func (s *LocationService) GetHistory(ctx context.Context, userID primitive.ObjectID, from, to time.Time, limit int) (*models.LocationHistoryResponse, error) {
+	settings, err := s.historySettings(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	if limit <= 0 || limit > historyMaxPoints {
+		limit = historyMaxPoints
+	}
+
+	filter := bson.M{"user_id": userID}
+	recorded := bson.M{}
+	if !from.IsZero() {
+		recorded["$gte"] = from
+	}
+	if !to.IsZero() {
+		recorded["$lt"] = to
+	}
+	if len(recorded) > 0 {
+		filter["recorded_at"] = recorded
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "recorded_at", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := s.MongoDB.LocationHistory().Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	points := []models.LocationPoint{}
+	if err := cursor.All(ctx, &points); err != nil {
+		return nil, err
+	}
+
+	return &models.LocationHistoryResponse{Settings: settings, Points: points}, nil
+}
+
+// UpdateHistorySettings opts in or out of location history. Shortening the
+// retention also shortens it for points already stored; opting out stops
+// recording but keeps existing points until they expire or are purged.
+func (s *LocationService) UpdateHistorySettings(ctx context.Context, userID primitive.ObjectID, req models.HistorySettingsRequest) error {
+	settings := models.HistorySettings{Enabled: req.Enabled, RetentionDays: req.RetentionDays}
+	limit := s.retention(models.HistorySettings{})
+	if time.Duration(req.RetentionDays)*24*time.Hour > limit {
+		return fmt.Errorf("retention_days cannot exceed %d", int(limit.Hours()/24))
+	}
+
+	_, err := s.MongoDB.Users().UpdateOne(
+		ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{
+			"location_history": settings,
+			"updated_at":       time.Now(),
+		}},
+	)
+	if err != nil {
+		return err
+	}
+
+	retention := s.retention(settings)
+	_, err = s.MongoDB.LocationHistory().UpdateMany(ctx, bson.M{"user_id": userID}, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"expires_at": bson.M{"$min": bson.A{"$expires_at", bson.M{"$add": bson.A{"$recorded_at", retention.Milliseconds()}}}},
+		}}},
+	})
+	return err
+}
+
+// PurgeLocation deletes the user's location history together with their
+// current location, zone and learned pace.
+func (s *LocationService) PurgeLocation(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	result, err := s.MongoDB.LocationHistory().DeleteMany(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, err
+	}
+
+	id := userID.Hex()
+	err = s.Redis.Client.Del(ctx,
+		fmt.Sprintf("user:location:%s", id),
+		fmt.Sprintf("user:history:%s", id),
+		zoneKey(id),
+		geofenceCacheKey(id),
+		paceKey(id),
+		paceAnchorKey(id),
+	).Err()
+	if err != nil {
+		return result.DeletedCount, err
+	}
+
+	log.Printf("🗑️ Purged %d location points for %s", result.DeletedCount, id)
+	return result.DeletedCount, nil
+}
+
+// StartHistoryService coarsens precise points once their trip has ended.
+// Expiry itself is left to the TTL index on expires_at.
+func (s *LocationService) StartHistoryService(ctx context.Context) {
+	ticker := time.NewTicker(15 * time.Minute)
+	defer ticker.Stop()
+
+	log.Println("🗺️ Started location history service")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping location history service")
+			return
+		case <-ticker.C:
+			s.coarsenHistory(ctx)
+		}
+	}
+}
+
+func (s *LocationService) coarsenHistory(ctx context.Context) {
+	result, err := s.MongoDB.LocationHistory().UpdateMany(
+		ctx,
+		bson.M{"precise": true, "coarsen_at": bson.M{"$lte": time.Now()}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"latitude":  bson.M{"$round": bson.A{"$latitude", coarseDecimals}},
+				"longitude": bson.M{"$round": bson.A{"$longitude", coarseDecimals}},
+				"precise":   false,
+			}}},
+			{{Key: "$unset", Value: "coarsen_at"}},
+		},
+	)
+	if err != nil {
+		log.Printf("Error coarsening location history: %v", err)
+		return
+	}
+	if result.ModifiedCount > 0 {
+		log.Printf("Coarsened %d location points after their trips ended", result.ModifiedCount)
+	}
+}
+
+func coarsen(v float64) float64 {
+	scale := math.Pow(10, coarseDecimals)
+	return math.Round(v*scale) / scale
+}
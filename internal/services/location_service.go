@@ -4,24 +4,41 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"math"
 	"time"
 
 	"github.com/onoja123/travel-companion-backend/internal/database"
 	"github.com/onoja123/travel-companion-backend/internal/models"
+	"github.com/onoja123/travel-companion-backend/internal/terminal"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type LocationService struct {
-	MongoDB *database.MongoDB
-	Redis   *database.RedisClient
+	MongoDB     *database.MongoDB
+	Redis       *database.RedisClient
+	Terminals   *terminal.Registry
+	TravelTimes TravelTimeProvider
+	Events      EventPublisher
+	Geofences   GeofenceHandler
+
+	// HistoryRetention caps how long opt-in location history is kept.
+	HistoryRetention time.Duration
+	// Holidays adjusts security wait forecasts on busy and quiet days.
+	Holidays *HolidayCalendar
 }
 
-func NewLocationService(db *database.MongoDB, redis *database.RedisClient) *LocationService {
+func NewLocationService(db *database.MongoDB, redis *database.RedisClient, terminals *terminal.Registry, events EventPublisher, geofences GeofenceHandler) *LocationService {
 	return &LocationService{
-		MongoDB: db,
-		Redis:   redis,
+		MongoDB:     db,
+		Redis:       redis,
+		Terminals:   terminals,
+		TravelTimes: NewSpeedModel(),
+		Events:      events,
+		Geofences:   geofences,
+
+		HistoryRetention: defaultHistoryRetention,
 	}
 }
 
@@ -46,7 +63,14 @@ func (s *LocationService) UpdateLocation(ctx context.Context, update models.Loca
 	}
 
 	key := fmt.Sprintf("user:location:%s", update.UserID)
-	return s.Redis.Client.Set(ctx, key, data, 10*time.Minute).Err()
+	if err := s.Redis.Client.Set(ctx, key, data, 10*time.Minute).Err(); err != nil {
+		return err
+	}
+
+	s.evaluateGeofences(ctx, &location)
+	s.recordHistory(ctx, &location)
+	s.recordPace(ctx, &location)
+	return nil
 }
 
 func (s *LocationService) GetWalkTime(ctx context.Context, flightID string, userID primitive.ObjectID) (*models.WalkTimeResponse, error) {
@@ -65,70 +89,49 @@ func (s *LocationService) GetWalkTime(ctx context.Context, flightID string, user
 	// Get flight details
 	flightObjID, _ := primitive.ObjectIDFromHex(flightID)
 	var flight models.TrackedFlight
-	err = s.MongoDB.TrackedFlights().FindOne(ctx, bson.M{"_id": flightObjID}).Decode(&flight)
+	err = s.MongoDB.TrackedFlights().FindOne(ctx, bson.M{"_id": flightObjID, "user_id": userID}).Decode(&flight)
 	if err != nil {
 		return nil, fmt.Errorf("flight not found")
 	}
 
-	// Get airport/gate location
+	// Get flight status for the gate and boarding time
+	flightKey := fmt.Sprintf("%s_%s", flight.FlightNumber, flight.DepartureDate.Format("2006-01-02"))
+	var status models.FlightStatus
+	s.MongoDB.FlightStatus().FindOne(ctx, bson.M{"flight_key": flightKey}).Decode(&status)
+
 	var airport models.Airport
 	err = s.MongoDB.Airports().FindOne(ctx, bson.M{"code": flight.DepartureAirport}).Decode(&airport)
 	if err != nil {
 		return nil, fmt.Errorf("airport not found")
 	}
 
-	// Calculate distance
-	distance := s.calculateDistance(userLoc.Latitude, userLoc.Longitude, airport.Latitude, airport.Longitude)
-
-	// Estimate walk time (average walking speed: 1.4 m/s or ~5 km/h)
-	walkTimeMinutes := int(distance / 1.4 / 60) // Convert to minutes
-
-	// Get flight status to determine urgency
-	flightKey := fmt.Sprintf("%s_%s", flight.FlightNumber, flight.DepartureDate.Format("2006-01-02"))
-	var status models.FlightStatus
-	s.MongoDB.FlightStatus().FindOne(ctx, bson.M{"flight_key": flightKey}).Decode(&status)
-
-	minutesUntilBoarding := int(time.Until(status.BoardingTime).Minutes())
-
-	urgencyLevel := "calm"
-	recommendedAction := "You have plenty of time"
-
-	if walkTimeMinutes > minutesUntilBoarding {
-		urgencyLevel = "critical"
-		recommendedAction = "Head to gate immediately!"
-	} else if walkTimeMinutes+10 > minutesUntilBoarding {
-		urgencyLevel = "urgent"
-		recommendedAction = "Start heading to gate now"
-	} else if walkTimeMinutes+20 > minutesUntilBoarding {
-		urgencyLevel = "moderate"
-		recommendedAction = "Consider heading to gate soon"
+	walkTime, err := s.timeToGate(ctx, &userLoc, &airport, &status, s.walkerFor(ctx, userID))
+	if err != nil {
+		return nil, err
 	}
 
-	return &models.WalkTimeResponse{
-		FlightID:          flightID,
-		DistanceMeters:    distance,
-		WalkTimeMinutes:   walkTimeMinutes,
-		UrgencyLevel:      urgencyLevel,
-		RecommendedAction: recommendedAction,
-	}, nil
+	walkTime.FlightID = flightID
+	return walkTime, nil
 }
 
-// Haversine formula to calculate distance between two coordinates
-func (s *LocationService) calculateDistance(lat1, lon1, lat2, lon2 float64) float64 {
-	const earthRadius = 6371000 // meters
-
-	lat1Rad := lat1 * math.Pi / 180
-	lat2Rad := lat2 * math.Pi / 180
-	deltaLat := (lat2 - lat1) * math.Pi / 180
-	deltaLon := (lon2 - lon1) * math.Pi / 180
-
-	a := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
-		math.Cos(lat1Rad)*math.Cos(lat2Rad)*
-			math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
-
-	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-
-	return earthRadius * c
+func routeSteps(route *terminal.Route, speed float64) []models.RouteStep {
+	steps := make([]models.RouteStep, len(route.Nodes))
+	for i, n := range route.Nodes {
+		steps[i] = models.RouteStep{
+			NodeID:    n.ID,
+			Name:      n.Name,
+			Type:      n.Type,
+			Terminal:  n.Terminal,
+			Latitude:  n.Latitude,
+			Longitude: n.Longitude,
+		}
+		if i > 0 {
+			e := route.Edges[i-1]
+			steps[i].Mode = e.Type
+			steps[i].Seconds = int(math.Round(e.Traversal(speed)))
+		}
+	}
+	return steps
 }
 
 func (s *LocationService) GetSecurityWaitTime(ctx context.Context, airportCode string) (*models.SecurityWaitTime, error) {
@@ -149,15 +152,38 @@ func (s *LocationService) GetSecurityWaitTime(ctx context.Context, airportCode s
 		return nil, err
 	}
 
+	now := time.Now()
 	waitTime := &models.SecurityWaitTime{
 		AirportCode:     airportCode,
 		CurrentWaitTime: airport.SecurityWaitAvg,
-		Timestamp:       time.Now().Format(time.RFC3339),
+		Source:          WaitSourceAverage,
+		Timestamp:       now.Format(time.RFC3339),
+	}
+
+	// A published wait replaces the average
+	if data, err := s.Redis.Client.Get(ctx, officialWaitKey(airportCode)).Bytes(); err == nil {
+		var official models.SecurityWaitTime
+		if json.Unmarshal(data, &official) == nil {
+			waitTime = &official
+		}
+	}
+	baseline := waitTime.CurrentWaitTime
+
+	// Blend in dwell times observed from travelers' traces
+	overall, checkpoints, err := s.crowdWait(ctx, airportCode, now)
+	if err != nil {
+		log.Printf("Error reading crowd wait times for %s: %v", airportCode, err)
+	} else if overall.SampleCount > 0 {
+		waitTime.CurrentWaitTime = blendWait(baseline, overall)
+		waitTime.Source = WaitSourceCrowd
+		waitTime.Confidence = overall.Confidence
+		waitTime.SampleCount = overall.SampleCount
+		waitTime.Checkpoints = checkpoints
 	}
 
-	// Cache for 15 minutes
+	// Cache briefly; new samples clear it
 	data, _ := json.Marshal(waitTime)
-	s.Redis.Client.Set(ctx, key, data, 15*time.Minute)
+	s.Redis.Client.Set(ctx, key, data, waitCacheTTL)
 
 	return waitTime, nil
 }
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/models"
+	"github.com/onoja123/travel-companion-backend/internal/terminal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Mobility profiles
+const (
+	MobilityStandard     = "standard"
+	MobilityWheelchair   = "wheelchair"
+	MobilityWithChildren = "with_children"
+	MobilityAssistance   = "assistance"
+)
+
+const (
+	// paceAlpha weights each new sample in the learned pace's moving average.
+	paceAlpha = 0.2
+	// paceMinSamples is how many samples the learned pace needs before it
+	// replaces the profile's speed.
+	paceMinSamples = 5
+	// paceTTL forgets a learned pace that has not been updated in a month.
+	paceTTL = 30 * 24 * time.Hour
+	// Fixes closer together than paceMinInterval are too noisy to measure
+	// a pace from; fixes further apart may not be one continuous walk. Each
+	// fix is measured against an anchor fix at least paceMinInterval older,
+	// so clients streaming several fixes a second still yield samples.
+	paceMinInterval = 3 * time.Second
+	paceMaxInterval = 2 * time.Minute
+	// Speeds outside this range are standing still or riding something.
+	paceMinSpeed = 0.3
+	paceMaxSpeed = 2.5
+)
+
+// walker is how a user gets around the terminal.
+type walker struct {
+	speed   float64  // meters per second
+	avoid   []string // edge types the route must not use
+	margin  int      // extra minutes of slack in the urgency thresholds
+	learned bool     // speed comes from the user's own pace
+}
+
+var mobilityProfiles = map[string]walker{
+	MobilityStandard:     {speed: terminal.WalkingSpeed},
+	MobilityWheelchair:   {speed: 1.1, avoid: []string{terminal.EdgeStairs, terminal.EdgeEscalator}, margin: 10},
+	MobilityWithChildren: {speed: 1.0, avoid: []string{terminal.EdgeStairs, terminal.EdgeEscalator}, margin: 10},
+	// Assisted travelers wait for an escort, so they get the most slack.
+	MobilityAssistance: {speed: 0.8, avoid: []string{terminal.EdgeStairs, terminal.EdgeEscalator}, margin: 20},
+}
+
+// walkerFor combines the user's mobility profile with their learned pace.
+func (s *LocationService) walkerFor(ctx context.Context, userID primitive.ObjectID) walker {
+	var user models.User
+	opts := options.FindOne().SetProjection(bson.M{"mobility": 1})
+	if err := s.MongoDB.Users().FindOne(ctx, bson.M{"_id": userID}, opts).Decode(&user); err != nil {
+		user.Mobility.Profile = MobilityStandard
+	}
+
+	w, ok := mobilityProfiles[user.Mobility.Profile]
+	if !ok {
+		w = mobilityProfiles[MobilityStandard]
+	}
+	if user.Mobility.AvoidStairs && len(w.avoid) == 0 {
+		w.avoid = []string{terminal.EdgeStairs}
+	}
+
+	if speed, samples := s.learnedPace(ctx, userID.Hex()); samples >= paceMinSamples {
+		w.speed = speed
+		w.learned = true
+	}
+	return w
+}
+
+// GetMobility returns the user's mobility profile and the pace used for
+// their walk times.
+func (s *LocationService) GetMobility(ctx context.Context, userID primitive.ObjectID) (*models.MobilityResponse, error) {
+	var user models.User
+	opts := options.FindOne().SetProjection(bson.M{"mobility": 1})
+	if err := s.MongoDB.Users().FindOne(ctx, bson.M{"_id": userID}, opts).Decode(&user); err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	profile := user.Mobility.Profile
+	if profile == "" {
+		profile = MobilityStandard
+	}
+
+	w := s.walkerFor(ctx, userID)
+	speed, samples := s.learnedPace(ctx, userID.Hex())
+
+	return &models.MobilityResponse{
+		Profile:        profile,
+		AvoidStairs:    user.Mobility.AvoidStairs,
+		WalkingSpeed:   math.Round(w.speed*100) / 100,
+		LearnedSpeed:   math.Round(speed*100) / 100,
+		LearnedSamples: samples,
+		Avoids:         w.avoid,
+	}, nil
+}
+
+func (s *LocationService) UpdateMobility(ctx context.Context, userID primitive.ObjectID, req models.MobilityProfileRequest) error {
+	_, err := s.MongoDB.Users().UpdateOne(
+		ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{
+			"mobility.profile":      req.Profile,
+			"mobility.avoid_stairs": req.AvoidStairs,
+			"updated_at":            time.Now(),
+		}},
+	)
+	return err
+}
+
+// ResetPace forgets the user's learned pace, e.g. after an injury heals.
+func (s *LocationService) ResetPace(ctx context.Context, userID primitive.ObjectID) error {
+	return s.Redis.Client.Del(ctx, paceKey(userID.Hex())).Err()
+}
+
+// recordPace folds the speed between the user's pace anchor and a new fix
+// into their learned pace, then makes the fix the anchor. Fixes too soon
+// after the anchor are skipped and leave it in place. Only fixes at an
+// airport count, so driving in slow traffic is not mistaken for walking.
+func (s *LocationService) recordPace(ctx context.Context, current *UserLocation) {
+	anchorKey := paceAnchorKey(current.UserID)
+
+	var previous UserLocation
+	data, err := s.Redis.Client.Get(ctx, anchorKey).Bytes()
+	found := err == nil && json.Unmarshal(data, &previous) == nil
+
+	elapsed := current.Timestamp.Sub(previous.Timestamp)
+	if found && elapsed < paceMinInterval {
+		return
+	}
+	if data, err := json.Marshal(current); err == nil {
+		s.Redis.Client.Set(ctx, anchorKey, data, paceMaxInterval)
+	}
+	if !found || elapsed > paceMaxInterval {
+		return
+	}
+
+	speed := terminal.Distance(previous.Latitude, previous.Longitude, current.Latitude, current.Longitude) / elapsed.Seconds()
+	if speed < paceMinSpeed || speed > paceMaxSpeed {
+		return
+	}
+
+	zone, err := s.zoneState(ctx, current.UserID)
+	if err != nil || zone.Zone == zoneOutside {
+		return
+	}
+
+	average, samples := s.learnedPace(ctx, current.UserID)
+	if samples == 0 {
+		average = speed
+	} else {
+		average = paceAlpha*speed + (1-paceAlpha)*average
+	}
+
+	key := paceKey(current.UserID)
+	pipe := s.Redis.Client.TxPipeline()
+	pipe.HSet(ctx, key, "speed", strconv.FormatFloat(average, 'f', 3, 64), "samples", samples+1)
+	pipe.Expire(ctx, key, paceTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Error saving pace for %s: %v", current.UserID, err)
+	}
+}
+
+func (s *LocationService) learnedPace(ctx context.Context, userID string) (float64, int) {
+	values, err := s.Redis.Client.HGetAll(ctx, paceKey(userID)).Result()
+	if err != nil || len(values) == 0 {
+		return 0, 0
+	}
+
+	speed, err := strconv.ParseFloat(values["speed"], 64)
+	if err != nil || speed <= 0 {
+		return 0, 0
+	}
+	samples, _ := strconv.Atoi(values["samples"])
+	return speed, samples
+}
+
+func paceKey(userID string) string {
+	return fmt.Sprintf("user:pace:%s", userID)
+}
+
+func paceAnchorKey(userID string) string {
+	return fmt.Sprintf("user:pace:anchor:%s", userID)
+}
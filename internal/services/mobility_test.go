@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/onoja123/travel-companion-backend/internal/database"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRecordPaceFromStreamedFixes(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	s := &LocationService{Redis: &database.RedisClient{Client: client}}
+	ctx := context.Background()
+	mr.Set(zoneKey("alice"), `{"airport":"JFK","zone":"airside"}`)
+
+	// Walking north at about 1.4 m/s, one fix a second
+	start := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	const metersPerDegree = 111195.0
+	for i := range 13 {
+		s.recordPace(ctx, &UserLocation{
+			UserID:    "alice",
+			Latitude:  40.6413 + float64(i)*1.4/metersPerDegree,
+			Longitude: -73.7781,
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	speed, samples := s.learnedPace(ctx, "alice")
+	if samples != 4 {
+		t.Errorf("%d samples, want one per %v", samples, paceMinInterval)
+	}
+	if speed < 1.3 || speed > 1.5 {
+		t.Errorf("learned speed %.2f m/s, want about 1.4", speed)
+	}
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/i18n"
+	"github.com/onoja123/travel-companion-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	deliveryInstant = "instant"
+	deliveryDigest  = "digest"
+
+	digestHourly = "hourly"
+	digestDaily  = "daily"
+
+	// tripConnectionWindow is how soon after a flight the next one may
+	// leave from its arrival airport and still be part of the same trip.
+	// Tracked flights only carry a departure date, so it spans two days.
+	tripConnectionWindow = 48 * time.Hour
+)
+
+// ErrChannelUnavailable is returned when a digest channel has no sender.
+var ErrChannelUnavailable = errors.New("digest channel is not available")
+
+// isTimeCritical reports whether a notification type is only useful right
+// away, so it is pushed even to digest users.
+func isTimeCritical(notifType string) bool {
+	switch notifType {
+	case "gate_change", "leave_for_airport", "airport_arrival":
+		return true
+	}
+	return strings.HasPrefix(notifType, "boarding_")
+}
+
+// ChannelSender delivers a rendered message over a channel other than push,
+// such as email or SMS.
+type ChannelSender interface {
+	Send(ctx context.Context, user *models.User, msg *i18n.Message) error
+}
+
+// RegisterChannel makes a non-push channel available for digest delivery.
+func (s *NotificationService) RegisterChannel(channel i18n.Channel, sender ChannelSender) {
+	s.Channels[channel] = sender
+}
+
+// digestGroup is the digest content for one flight.
+type digestGroup struct {
+	FlightKey     string
+	FlightNumber  string
+	Notifications []models.Notification
+}
+
+// digestTrip is the digest content for one trip: connecting flights, each
+// leaving from where the previous one arrived.
+type digestTrip struct {
+	Route   string // e.g. "JFK → LHR → FRA", or the flight number when unknown
+	Flights []digestGroup
+}
+
+// Background service for digest delivery
+func (s *NotificationService) StartDigestService(ctx context.Context) {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	log.Println("📬 Started notification digest service")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping digest service")
+			return
+		case <-ticker.C:
+			s.SendDueDigests(ctx)
+		}
+	}
+}
+
+// SendDueDigests sends a summary to every digest user whose period has elapsed.
+func (s *NotificationService) SendDueDigests(ctx context.Context) {
+	cursor, err := s.MongoDB.Users().Find(ctx, bson.M{"preferences.delivery_mode": deliveryDigest})
+	if err != nil {
+		log.Printf("Error fetching digest users: %v", err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		log.Printf("Error decoding digest users: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for i := range users {
+		if isDigestDue(&users[i], now) {
+			s.sendDigest(ctx, &users[i], now)
+		}
+	}
+}
+
+func (s *NotificationService) sendDigest(ctx context.Context, user *models.User, now time.Time) {
+	opts := options.Find().SetSort(bson.D{{Key: "sent_at", Value: 1}})
+	cursor, err := s.MongoDB.Notifications().Find(ctx, bson.M{
+		"user_id":     user.ID,
+		"delivery":    deliveryDigest,
+		"digested_at": nil,
+		"sent_at":     bson.M{"$lte": now},
+	}, opts)
+	if err != nil {
+		log.Printf("Error fetching digest notifications for %s: %v", user.ID.Hex(), err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var pending []models.Notification
+	if err := cursor.All(ctx, &pending); err != nil {
+		log.Printf("Error decoding digest notifications: %v", err)
+		return
+	}
+
+	if len(pending) > 0 {
+		if err := s.deliverDigest(ctx, user, pending); err != nil {
+			log.Printf("Error sending digest to %s: %v", user.ID.Hex(), err)
+			return // retried on the next tick
+		}
+
+		ids := make([]primitive.ObjectID, len(pending))
+		for i, n := range pending {
+			ids[i] = n.ID
+		}
+		s.MongoDB.Notifications().UpdateMany(
+			ctx,
+			bson.M{"_id": bson.M{"$in": ids}},
+			bson.M{"$set": bson.M{"digested_at": now}},
+		)
+	}
+
+	s.MongoDB.Users().UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$set": bson.M{"last_digest_at": now}})
+}
+
+func (s *NotificationService) deliverDigest(ctx context.Context, user *models.User, pending []models.Notification) error {
+	trips := groupTrips(groupDigest(pending), s.trackedFlights(ctx, user.ID))
+	var groups []digestGroup
+	for _, trip := range trips {
+		groups = append(groups, trip.Flights...)
+	}
+
+	channel := i18n.Channel(user.Preferences.DigestChannel)
+	sender, ok := s.Channels[channel]
+	if channel != i18n.ChannelPush && !ok {
+		log.Printf("No sender for %s digests, falling back to push", channel)
+		channel = i18n.ChannelPush
+	}
+
+	msg, err := s.Templates.Render(channel, "digest", i18n.Params{
+		Locale:   user.Locale,
+		Timezone: user.Preferences.DigestTimezone,
+		Data: map[string]interface{}{
+			"Count":       len(pending),
+			"FlightCount": len(groups),
+			"TripCount":   len(trips),
+			"Groups":      groups,
+			"Trips":       trips,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if channel != i18n.ChannelPush {
+		return sender.Send(ctx, user, msg)
+	}
+
+	devices, err := s.Devices.GetUserDevices(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]string{"type": deliveryDigest}
+	s.dispatch(ctx, s.pushMessages(devices, msg, data))
+	return nil
+}
+
+// groupDigest groups notifications by flight, keeping first-seen order.
+func groupDigest(notifications []models.Notification) []digestGroup {
+	var groups []digestGroup
+	index := make(map[string]int)
+
+	for _, n := range notifications {
+		i, ok := index[n.FlightKey]
+		if !ok {
+			flightNumber, _, _ := strings.Cut(n.FlightKey, "_")
+			groups = append(groups, digestGroup{FlightKey: n.FlightKey, FlightNumber: flightNumber})
+			i = len(groups) - 1
+			index[n.FlightKey] = i
+		}
+		groups[i].Notifications = append(groups[i].Notifications, n)
+	}
+
+	return groups
+}
+
+// trackedFlights returns the user's tracked flights by flight key.
+func (s *NotificationService) trackedFlights(ctx context.Context, userID primitive.ObjectID) map[string]models.TrackedFlight {
+	cursor, err := s.MongoDB.TrackedFlights().Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		log.Printf("Error fetching tracked flights for %s: %v", userID.Hex(), err)
+		return nil
+	}
+	defer cursor.Close(ctx)
+
+	var flights []models.TrackedFlight
+	if err := cursor.All(ctx, &flights); err != nil {
+		log.Printf("Error decoding tracked flights: %v", err)
+		return nil
+	}
+
+	byKey := make(map[string]models.TrackedFlight, len(flights))
+	for _, flight := range flights {
+		byKey[fmt.Sprintf("%s_%s", flight.FlightNumber, flight.DepartureDate.Format("2006-01-02"))] = flight
+	}
+	return byKey
+}
+
+// groupTrips chains flight groups into trips in departure order. A flight
+// joins the previous trip when it leaves, within tripConnectionWindow, from
+// the airport the trip's last flight arrived at. Flights that are not
+// tracked (or no longer) each make a trip of their own, last.
+func groupTrips(groups []digestGroup, tracked map[string]models.TrackedFlight) []digestTrip {
+	sorted := append([]digestGroup(nil), groups...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, aok := tracked[sorted[i].FlightKey]
+		b, bok := tracked[sorted[j].FlightKey]
+		if aok != bok {
+			return aok
+		}
+		return aok && a.DepartureDate.Before(b.DepartureDate)
+	})
+
+	var trips []digestTrip
+	var last *models.TrackedFlight
+	for _, group := range sorted {
+		flight, ok := tracked[group.FlightKey]
+		if !ok {
+			trips = append(trips, digestTrip{Route: group.FlightNumber, Flights: []digestGroup{group}})
+			last = nil
+			continue
+		}
+
+		connects := last != nil &&
+			last.ArrivalAirport != "" &&
+			flight.DepartureAirport == last.ArrivalAirport &&
+			flight.DepartureDate.Sub(last.DepartureDate) <= tripConnectionWindow
+		if connects {
+			trip := &trips[len(trips)-1]
+			trip.Flights = append(trip.Flights, group)
+			trip.Route += " → " + flight.ArrivalAirport
+		} else {
+			trips = append(trips, digestTrip{
+				Route:   flight.DepartureAirport + " → " + flight.ArrivalAirport,
+				Flights: []digestGroup{group},
+			})
+		}
+		last = &flight
+	}
+
+	return trips
+}
+
+// isDigestDue reports whether the user's digest period has elapsed. Hourly
+// digests go out once per clock hour; daily digests once a day at
+// DigestHour in the user's DigestTimezone.
+func isDigestDue(user *models.User, now time.Time) bool {
+	var scheduled time.Time
+
+	switch user.Preferences.DigestFrequency {
+	case digestDaily:
+		loc := time.UTC
+		if user.Preferences.DigestTimezone != "" {
+			if l, err := time.LoadLocation(user.Preferences.DigestTimezone); err == nil {
+				loc = l
+			}
+		}
+
+		local := now.In(loc)
+		scheduled = time.Date(local.Year(), local.Month(), local.Day(), user.Preferences.DigestHour, 0, 0, 0, loc)
+		if local.Before(scheduled) {
+			return false
+		}
+	default:
+		scheduled = now.Truncate(time.Hour)
+	}
+
+	return user.LastDigest == nil || user.LastDigest.Before(scheduled)
+}
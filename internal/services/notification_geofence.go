@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// HandleGeofenceEvent sends context-aware notifications for airport
+// geofence transitions. Arriving landside for a departure brings the
+// current security wait; other transitions are realtime-only.
+func (s *NotificationService) HandleGeofenceEvent(ctx context.Context, userID primitive.ObjectID, eventType string, event models.GeofenceEvent, flight *models.FlightStatus) {
+	if eventType != models.EventAirportEntered || event.Zone != zoneLandside || flight == nil {
+		return
+	}
+
+	user, ok := s.geofenceRecipient(ctx, userID)
+	if !ok {
+		return
+	}
+
+	data := flightTemplateData(flight)
+	data["Airport"] = event.AirportCode
+	data["WaitMinutes"] = event.SecurityWaitMinutes
+
+	s.sendToUser(ctx, user, flight, "airport_arrival", "normal", data, map[string]string{
+		"airport":      event.AirportCode,
+		"wait_minutes": fmt.Sprintf("%d", event.SecurityWaitMinutes),
+	})
+}
+
+// SendLeaveForAirport nudges a user who is still away from the airport that
+// it is time to set off.
+func (s *NotificationService) SendLeaveForAirport(ctx context.Context, userID primitive.ObjectID, flight *models.FlightStatus, estimate *models.WalkTimeResponse) {
+	user, ok := s.geofenceRecipient(ctx, userID)
+	if !ok {
+		return
+	}
+
+	data := flightTemplateData(flight)
+	data["TotalMinutes"] = estimate.TotalMinutes
+	data["LeaveBy"] = *estimate.LeaveBy
+
+	s.sendToUser(ctx, user, flight, "leave_for_airport", "high", data, map[string]string{
+		"flight_id": estimate.FlightID,
+		"leave_by":  estimate.LeaveBy.Format(time.RFC3339),
+	})
+}
+
+// geofenceRecipient loads the user if they want boarding-related alerts.
+func (s *NotificationService) geofenceRecipient(ctx context.Context, userID primitive.ObjectID) (*models.User, bool) {
+	var user models.User
+	if err := s.MongoDB.Users().FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		log.Printf("Error loading user %s: %v", userID.Hex(), err)
+		return nil, false
+	}
+	return &user, user.Preferences.NotifyBoarding
+}
+
+func (s *NotificationService) sendToUser(ctx context.Context, user *models.User, flight *models.FlightStatus, notifType, priority string, data map[string]interface{}, pushData map[string]string) {
+	devices, err := s.Devices.GetUserDevices(ctx, user.ID)
+	if err != nil {
+		log.Printf("Error fetching devices for %s: %v", user.ID.Hex(), err)
+	}
+
+	out := &outbox{}
+	s.compose(out, user, devices, flight, notifType, priority, data, pushData)
+	s.send(ctx, out)
+}
@@ -2,127 +2,240 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/onoja123/travel-companion-backend/internal/database"
+	"github.com/onoja123/travel-companion-backend/internal/i18n"
 	"github.com/onoja123/travel-companion-backend/internal/models"
 	"github.com/onoja123/travel-companion-backend/pkg/fcm"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrInvalidCursor is returned when an inbox pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrNotificationNotFound is returned for a notification that does not
+// exist or belongs to another user.
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// pushConcurrency bounds how many FCM batches are in flight at once.
+const pushConcurrency = 8
+
 type NotificationService struct {
 	MongoDB    *database.MongoDB
 	FCMService *fcm.FCMService
+	Dispatcher *fcm.Dispatcher
+	Devices    *DeviceService
+	Templates  *i18n.Renderer
+	Channels   map[i18n.Channel]ChannelSender
 }
 
-func NewNotificationService(db *database.MongoDB, fcmService *fcm.FCMService) *NotificationService {
-	return &NotificationService{
+func NewNotificationService(db *database.MongoDB, fcmService *fcm.FCMService, devices *DeviceService, templates *i18n.Renderer) *NotificationService {
+	svc := &NotificationService{
 		MongoDB:    db,
 		FCMService: fcmService,
+		Devices:    devices,
+		Templates:  templates,
+		Channels:   make(map[i18n.Channel]ChannelSender),
+	}
+
+	if fcmService != nil {
+		svc.Dispatcher = fcm.NewDispatcher(fcmService, pushConcurrency)
 	}
+
+	return svc
 }
 
-func (s *NotificationService) HandleFlightChanges(ctx context.Context, userID primitive.ObjectID, flight *models.FlightStatus, changes map[string]interface{}) {
-	// Get user to check FCM token and preferences
-	var user models.User
-	err := s.MongoDB.Users().FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+// HandleFlightChanges notifies every follower of a flight about the detected
+// changes. Messages for all followers are rendered first and then pushed in
+// batches, so a gate change on a full flight goes out in a few FCM calls.
+func (s *NotificationService) HandleFlightChanges(ctx context.Context, userIDs []primitive.ObjectID, flight *models.FlightStatus, changes map[string]interface{}) {
+	// Get users to check preferences
+	cursor, err := s.MongoDB.Users().Find(ctx, bson.M{"_id": bson.M{"$in": userIDs}})
 	if err != nil {
-		log.Printf("Error finding user: %v", err)
+		log.Printf("Error finding users: %v", err)
 		return
 	}
+	defer cursor.Close(ctx)
 
-	if user.FCMToken == "" {
-		return // No FCM token, skip notification
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		log.Printf("Error decoding users: %v", err)
+		return
 	}
 
-	// Handle gate change
-	if gateChange, ok := changes["gate"].(map[string]string); ok && user.Preferences.NotifyGateChange {
-		s.sendGateChangeNotification(ctx, &user, flight, gateChange)
+	devices, err := s.Devices.GetDevicesForUsers(ctx, userIDs)
+	if err != nil {
+		log.Printf("Error fetching devices: %v", err)
 	}
 
-	// Handle status change
-	if statusChange, ok := changes["status"].(map[string]string); ok && user.Preferences.NotifyBoarding {
-		s.sendStatusChangeNotification(ctx, &user, flight, statusChange)
-	}
+	out := &outbox{}
+	for i := range users {
+		user := &users[i]
+		userDevices := devices[user.ID]
+
+		// Handle gate change
+		if gateChange, ok := changes["gate"].(map[string]string); ok && user.Preferences.NotifyGateChange {
+			s.gateChangeNotification(out, user, userDevices, flight, gateChange)
+		}
+
+		// Handle status change
+		if statusChange, ok := changes["status"].(map[string]string); ok && user.Preferences.NotifyBoarding {
+			s.statusChangeNotification(out, user, userDevices, flight, statusChange)
+		}
 
-	// Handle delay
-	if delayChange, ok := changes["delay"].(map[string]int); ok && user.Preferences.NotifyDelay {
-		s.sendDelayNotification(ctx, &user, flight, delayChange)
+		// Handle delay
+		if delayChange, ok := changes["delay"].(map[string]int); ok && user.Preferences.NotifyDelay {
+			s.delayNotification(out, user, userDevices, flight, delayChange)
+		}
 	}
+
+	s.send(ctx, out)
 }
 
-func (s *NotificationService) sendGateChangeNotification(ctx context.Context, user *models.User, flight *models.FlightStatus, change map[string]string) {
-	title := "⚡ Gate Changed"
-	body := fmt.Sprintf("%s moved from Gate %s to Gate %s", flight.FlightNumber, change["old"], change["new"])
+func (s *NotificationService) gateChangeNotification(out *outbox, user *models.User, devices []models.Device, flight *models.FlightStatus, change map[string]string) {
+	data := flightTemplateData(flight)
+	data["OldGate"] = change["old"]
+	data["NewGate"] = change["new"]
 
-	notification := &models.Notification{
-		ID:        primitive.NewObjectID(),
-		UserID:    user.ID,
-		FlightKey: flight.FlightKey,
-		Type:      "gate_change",
-		Title:     title,
-		Body:      body,
-		Priority:  "high",
-		SentAt:    time.Now(),
-	}
+	s.compose(out, user, devices, flight, "gate_change", "high", data, map[string]string{
+		"new_gate": change["new"],
+	})
+}
+
+func (s *NotificationService) statusChangeNotification(out *outbox, user *models.User, devices []models.Device, flight *models.FlightStatus, change map[string]string) {
+	data := flightTemplateData(flight)
+	data["Status"] = change["new"]
 
-	// Save to database
-	s.MongoDB.Notifications().InsertOne(ctx, notification)
+	s.compose(out, user, devices, flight, "status_change", "normal", data, map[string]string{})
+}
 
-	// Send via FCM
-	s.FCMService.SendNotification(user.FCMToken, title, body, map[string]string{
-		"type":       "gate_change",
-		"flight_key": flight.FlightKey,
-		"new_gate":   change["new"],
+func (s *NotificationService) delayNotification(out *outbox, user *models.User, devices []models.Device, flight *models.FlightStatus, change map[string]int) {
+	data := flightTemplateData(flight)
+	data["DelayMinutes"] = change["new"]
+	data["NewDepartureTime"] = flight.DepartureTime.Add(time.Duration(change["new"]) * time.Minute)
+
+	s.compose(out, user, devices, flight, "delay", "high", data, map[string]string{
+		"delay": fmt.Sprintf("%d", change["new"]),
 	})
 }
 
-func (s *NotificationService) sendStatusChangeNotification(ctx context.Context, user *models.User, flight *models.FlightStatus, change map[string]string) {
-	title := fmt.Sprintf("Flight %s", change["new"])
-	body := fmt.Sprintf("%s status changed to %s", flight.FlightNumber, change["new"])
+// outbox collects rendered notifications and their pushes, so a fan-out is
+// stored with one InsertMany and sent in FCM batches.
+type outbox struct {
+	notifications []interface{}
+	messages      []fcm.Message
+}
+
+// compose renders a templated message in the user's locale and adds it,
+// with one push per device, to out. The template key doubles as the
+// notification type.
+func (s *NotificationService) compose(out *outbox, user *models.User, devices []models.Device, flight *models.FlightStatus, notifType, priority string, data map[string]interface{}, pushData map[string]string) {
+	msg, err := s.Templates.Render(i18n.ChannelPush, notifType, i18n.Params{
+		Locale:   user.Locale,
+		Timezone: flight.Timezone,
+		Data:     data,
+	})
+	if err != nil {
+		log.Printf("Error rendering %s notification: %v", notifType, err)
+		return
+	}
 
 	notification := &models.Notification{
 		ID:        primitive.NewObjectID(),
 		UserID:    user.ID,
 		FlightKey: flight.FlightKey,
-		Type:      "status_change",
-		Title:     title,
-		Body:      body,
-		Priority:  "normal",
+		Type:      notifType,
+		Title:     msg.Title,
+		Body:      msg.Body,
+		Priority:  priority,
 		SentAt:    time.Now(),
+		Delivery:  deliveryInstant,
 	}
 
-	s.MongoDB.Notifications().InsertOne(ctx, notification)
-	s.FCMService.SendNotification(user.FCMToken, title, body, map[string]string{
-		"type":       "status_change",
-		"flight_key": flight.FlightKey,
-	})
+	// Digest users get this in their next summary instead of a push, unless
+	// it is only useful right away
+	if user.Preferences.DeliveryMode == deliveryDigest && !isTimeCritical(notifType) {
+		notification.Delivery = deliveryDigest
+	}
+
+	out.notifications = append(out.notifications, notification)
+
+	if notification.Delivery == deliveryDigest {
+		return
+	}
+
+	pushData["type"] = notifType
+	pushData["flight_key"] = flight.FlightKey
+
+	out.messages = append(out.messages, s.pushMessages(devices, msg, pushData)...)
 }
 
-func (s *NotificationService) sendDelayNotification(ctx context.Context, user *models.User, flight *models.FlightStatus, change map[string]int) {
-	title := "⏰ Flight Delayed"
-	body := fmt.Sprintf("%s is delayed by %d minutes", flight.FlightNumber, change["new"])
+// send stores the outbox's notifications and pushes its messages.
+func (s *NotificationService) send(ctx context.Context, out *outbox) {
+	if len(out.notifications) > 0 {
+		if _, err := s.MongoDB.Notifications().InsertMany(ctx, out.notifications, options.InsertMany().SetOrdered(false)); err != nil {
+			log.Printf("Error saving %d notification(s): %v", len(out.notifications), err)
+		}
+	}
 
-	notification := &models.Notification{
-		ID:        primitive.NewObjectID(),
-		UserID:    user.ID,
-		FlightKey: flight.FlightKey,
-		Type:      "delay",
-		Title:     title,
-		Body:      body,
-		Priority:  "high",
-		SentAt:    time.Now(),
+	s.dispatch(ctx, out.messages)
+}
+
+// pushMessages addresses a rendered message to each of the given devices.
+func (s *NotificationService) pushMessages(devices []models.Device, msg *i18n.Message, data map[string]string) []fcm.Message {
+	messages := make([]fcm.Message, 0, len(devices))
+	for _, device := range devices {
+		messages = append(messages, fcm.Message{
+			Token: device.Token,
+			Title: msg.Title,
+			Body:  msg.Body,
+			Data:  data,
+		})
 	}
 
-	s.MongoDB.Notifications().InsertOne(ctx, notification)
-	s.FCMService.SendNotification(user.FCMToken, title, body, map[string]string{
-		"type":       "delay",
-		"flight_key": flight.FlightKey,
-		"delay":      fmt.Sprintf("%d", change["new"]),
-	})
+	return messages
+}
+
+// dispatch sends pushes via FCM and prunes tokens FCM rejects as
+// unregistered or invalid.
+func (s *NotificationService) dispatch(ctx context.Context, messages []fcm.Message) {
+	if s.Dispatcher == nil || len(messages) == 0 {
+		return
+	}
+
+	result := s.Dispatcher.Dispatch(ctx, messages)
+	if result.FailureCount > 0 {
+		log.Printf("FCM: %d sent, %d failed (%d invalid tokens)", result.SuccessCount, result.FailureCount, len(result.InvalidTokens))
+	}
+
+	if removed, err := s.Devices.RemoveTokens(ctx, result.InvalidTokens); err != nil {
+		log.Printf("Error pruning invalid FCM tokens: %v", err)
+	} else if removed > 0 {
+		log.Printf("Pruned %d invalid FCM token(s)", removed)
+	}
+}
+
+// flightTemplateData returns the template fields shared by all flight messages.
+func flightTemplateData(flight *models.FlightStatus) map[string]interface{} {
+	return map[string]interface{}{
+		"FlightNumber":     flight.FlightNumber,
+		"Gate":             flight.Gate,
+		"Terminal":         flight.Terminal,
+		"Status":           flight.Status,
+		"BoardingTime":     flight.BoardingTime,
+		"DepartureTime":    flight.DepartureTime,
+		"NewDepartureTime": flight.DepartureTime,
+		"DelayMinutes":     flight.DelayMinutes,
+	}
 }
 
 func (s *NotificationService) CheckBoardingReminders(ctx context.Context) {
@@ -154,7 +267,7 @@ func (s *NotificationService) checkBoardingReminderForFlight(ctx context.Context
 	// Get user
 	var user models.User
 	err = s.MongoDB.Users().FindOne(ctx, bson.M{"_id": flight.UserID}).Decode(&user)
-	if err != nil || user.FCMToken == "" {
+	if err != nil {
 		return
 	}
 
@@ -172,54 +285,205 @@ func (s *NotificationService) checkBoardingReminderForFlight(ctx context.Context
 }
 
 func (s *NotificationService) sendBoardingReminder(ctx context.Context, user *models.User, flight *models.FlightStatus, minutes int) {
-	var title, priority string
-
-	switch minutes {
-	case 40:
-		title = "⏰ Start Heading to Gate"
+	priority := "high"
+	if minutes == 40 {
 		priority = "normal"
-	case 20:
-		title = "🚨 Boarding Soon"
-		priority = "high"
-	case 10:
-		title = "🔴 FINAL CALL"
-		priority = "high"
 	}
 
-	body := fmt.Sprintf("%s boards in %d minutes - Gate %s", flight.FlightNumber, minutes, flight.Gate)
+	data := flightTemplateData(flight)
+	data["Minutes"] = minutes
 
-	notification := &models.Notification{
-		ID:        primitive.NewObjectID(),
-		UserID:    user.ID,
-		FlightKey: flight.FlightKey,
-		Type:      fmt.Sprintf("boarding_%d", minutes),
-		Title:     title,
-		Body:      body,
-		Priority:  priority,
-		SentAt:    time.Now(),
+	devices, err := s.Devices.GetUserDevices(ctx, user.ID)
+	if err != nil {
+		log.Printf("Error fetching devices for %s: %v", user.ID.Hex(), err)
 	}
 
-	s.MongoDB.Notifications().InsertOne(ctx, notification)
-	s.FCMService.SendNotification(user.FCMToken, title, body, map[string]string{
-		"type":       fmt.Sprintf("boarding_%d", minutes),
-		"flight_key": flight.FlightKey,
-		"gate":       flight.Gate,
+	out := &outbox{}
+	s.compose(out, user, devices, flight, fmt.Sprintf("boarding_%d", minutes), priority, data, map[string]string{
+		"gate": flight.Gate,
 	})
+	s.send(ctx, out)
 }
 
-func (s *NotificationService) GetUserNotifications(ctx context.Context, userID primitive.ObjectID) ([]models.Notification, error) {
-	cursor, err := s.MongoDB.Notifications().Find(ctx, bson.M{"user_id": userID})
+func (s *NotificationService) GetUserNotifications(ctx context.Context, userID primitive.ObjectID, query models.NotificationQuery) (*models.NotificationPage, error) {
+	filter := bson.M{"user_id": userID}
+	if query.Type != "" {
+		filter["type"] = query.Type
+	}
+	if query.FlightKey != "" {
+		filter["flight_key"] = query.FlightKey
+	}
+	if query.UnreadOnly {
+		filter["read_at"] = nil
+	}
+
+	if query.Cursor != "" {
+		sentAt, id, err := decodeNotificationCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter["$or"] = bson.A{
+			bson.M{"sent_at": bson.M{"$lt": sentAt}},
+			bson.M{"sent_at": sentAt, "_id": bson.M{"$lt": id}},
+		}
+	}
+
+	limit := query.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	// Fetch one extra document to know whether another page exists
+	opts := options.Find().
+		SetSort(bson.D{{Key: "sent_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit + 1))
+
+	cursor, err := s.MongoDB.Notifications().Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
-	var notifications []models.Notification
+	notifications := []models.Notification{}
 	if err := cursor.All(ctx, &notifications); err != nil {
 		return nil, err
 	}
 
-	return notifications, nil
+	page := &models.NotificationPage{Notifications: notifications}
+	if len(notifications) > limit {
+		page.Notifications = notifications[:limit]
+		last := page.Notifications[limit-1]
+		page.NextCursor = encodeNotificationCursor(last.SentAt, last.ID)
+	}
+
+	return page, nil
+}
+
+func (s *NotificationService) GetUnreadCount(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return s.MongoDB.Notifications().CountDocuments(ctx, bson.M{
+		"user_id": userID,
+		"read_at": nil,
+	})
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, notificationID, userID primitive.ObjectID) error {
+	result, err := s.MongoDB.Notifications().UpdateOne(
+		ctx,
+		bson.M{"_id": notificationID, "user_id": userID},
+		bson.M{"$set": bson.M{"read_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNotificationNotFound
+	}
+
+	return nil
+}
+
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	result, err := s.MongoDB.Notifications().UpdateMany(
+		ctx,
+		bson.M{"user_id": userID, "read_at": nil},
+		bson.M{"$set": bson.M{"read_at": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+func (s *NotificationService) DeleteNotification(ctx context.Context, notificationID, userID primitive.ObjectID) error {
+	result, err := s.MongoDB.Notifications().DeleteOne(ctx, bson.M{"_id": notificationID, "user_id": userID})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrNotificationNotFound
+	}
+
+	return nil
+}
+
+// Inbox cursors are opaque to clients: base64("<sent_at unix nanos>:<object id>")
+func encodeNotificationCursor(sentAt time.Time, id primitive.ObjectID) string {
+	raw := fmt.Sprintf("%d:%s", sentAt.UnixNano(), id.Hex())
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeNotificationCursor(cursor string) (time.Time, primitive.ObjectID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, ErrInvalidCursor
+	}
+
+	nanos, hexID, found := strings.Cut(string(raw), ":")
+	if !found {
+		return time.Time{}, primitive.NilObjectID, ErrInvalidCursor
+	}
+
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, ErrInvalidCursor
+	}
+
+	id, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, ErrInvalidCursor
+	}
+
+	return time.Unix(0, n), id, nil
+}
+
+func (s *NotificationService) UpdateDigestPreferences(ctx context.Context, userID primitive.ObjectID, prefs models.DigestPreferencesRequest) error {
+	if prefs.DigestTimezone != "" {
+		if _, err := time.LoadLocation(prefs.DigestTimezone); err != nil {
+			return fmt.Errorf("invalid timezone: %s", prefs.DigestTimezone)
+		}
+	}
+
+	frequency := prefs.DigestFrequency
+	if frequency == "" {
+		frequency = digestHourly
+	}
+	channel := prefs.DigestChannel
+	if channel == "" {
+		channel = string(i18n.ChannelPush)
+	}
+	if _, ok := s.Channels[i18n.Channel(channel)]; channel != string(i18n.ChannelPush) && !ok {
+		return fmt.Errorf("%w: %s", ErrChannelUnavailable, channel)
+	}
+
+	var user models.User
+	if err := s.MongoDB.Users().FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		return err
+	}
+
+	_, err := s.MongoDB.Users().UpdateOne(
+		ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{
+			"preferences.delivery_mode":    prefs.DeliveryMode,
+			"preferences.digest_frequency": frequency,
+			"preferences.digest_channel":   channel,
+			"preferences.digest_hour":      prefs.DigestHour,
+			"preferences.digest_timezone":  prefs.DigestTimezone,
+			"updated_at":                   time.Now(),
+		}},
+	)
+	if err != nil {
+		return err
+	}
+
+	// Leaving digest mode sends what was held back, with the old settings
+	if user.Preferences.DeliveryMode == deliveryDigest && prefs.DeliveryMode != deliveryDigest {
+		s.sendDigest(ctx, &user, time.Now())
+	}
+	return nil
 }
 
 func (s *NotificationService) UpdatePreferences(ctx context.Context, userID primitive.ObjectID, prefs models.NotificationPreferencesRequest) error {
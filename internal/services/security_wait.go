@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/models"
+	"github.com/onoja123/travel-companion-backend/internal/terminal"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// dwellBucket groups checkpoint dwell samples by when they ended.
+	dwellBucket = 15 * time.Minute
+	// dwellWindow is how far back samples count toward the current wait.
+	dwellWindow = time.Hour
+	// dwellRetention is how long each bucket is kept in Redis.
+	dwellRetention = 24 * time.Hour
+	// Dwells outside this range are GPS noise or travelers who lingered
+	// landside (check-in, shops) rather than queued.
+	dwellMin = time.Minute
+	dwellMax = 3 * time.Hour
+	// dwellPrior is the sample count at which crowd data and the airport's
+	// average weigh equally; confidence is n / (n + dwellPrior).
+	dwellPrior = 5
+	// waitCacheTTL caches a computed wait; new samples invalidate it.
+	waitCacheTTL = 5 * time.Minute
+	// defaultCheckpoint labels samples at airports without a terminal graph.
+	defaultCheckpoint = "main"
+	// dwellAirportsKey is the set of airports that have ever had samples.
+	dwellAirportsKey = "dwell:airports"
+)
+
+// Wait time sources
+const (
+	WaitSourceAverage = "average"
+	WaitSourceCrowd   = "crowd"
+)
+
+// recordDwell stores how long a traveler took from entering the landside
+// zone to being airside, attributed to the nearest checkpoint. Samples carry
+// no user identifier.
+func (s *LocationService) recordDwell(ctx context.Context, airport, checkpoint string, dwell time.Duration, at time.Time) {
+	if dwell < dwellMin || dwell > dwellMax {
+		return
+	}
+
+	airport = strings.ToUpper(airport)
+	key := dwellKey(airport, checkpoint, at.Truncate(dwellBucket))
+	checkpoints := fmt.Sprintf("airport:checkpoints:%s", airport)
+
+	pipe := s.Redis.Client.TxPipeline()
+	pipe.RPush(ctx, key, int(dwell.Seconds()))
+	pipe.Expire(ctx, key, dwellRetention)
+	pipe.SAdd(ctx, checkpoints, checkpoint)
+	pipe.Expire(ctx, checkpoints, dwellRetention)
+	pipe.SAdd(ctx, dwellAirportsKey, airport)
+	pipe.Del(ctx, fmt.Sprintf("airport:wait:%s", airport))
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Error recording security dwell at %s: %v", airport, err)
+	}
+}
+
+// checkpointFor names the checkpoint a traveler just cleared: the security
+// node nearest their first airside fix, else the terminal, else "main".
+func (s *LocationService) checkpointFor(loc *UserLocation, zone zoneState) string {
+	if graph, ok := s.Terminals.Get(zone.Airport); ok {
+		if node := nearestOfType(graph, loc.Latitude, loc.Longitude, terminal.NodeSecurity); node != nil {
+			return node.ID
+		}
+	}
+	if zone.Terminal != "" {
+		return zone.Terminal
+	}
+	return defaultCheckpoint
+}
+
+// crowdWait summarizes recent dwell samples per checkpoint and for the
+// whole airport.
+func (s *LocationService) crowdWait(ctx context.Context, airport string, now time.Time) (overall models.CheckpointWait, checkpoints []models.CheckpointWait, err error) {
+	airport = strings.ToUpper(airport)
+	names, err := s.Redis.Client.SMembers(ctx, fmt.Sprintf("airport:checkpoints:%s", airport)).Result()
+	if err != nil {
+		return overall, nil, err
+	}
+	sort.Strings(names)
+
+	var all []float64
+	for _, name := range names {
+		samples, err := s.dwellSamples(ctx, airport, name, now.Add(-dwellWindow), now)
+		if err != nil {
+			return overall, nil, err
+		}
+		if len(samples) == 0 {
+			continue
+		}
+		all = append(all, samples...)
+		checkpoints = append(checkpoints, summarizeDwell(name, samples))
+	}
+
+	overall = summarizeDwell("", all)
+	return overall, checkpoints, nil
+}
+
+// dwellSamples returns the dwell seconds recorded in the buckets that
+// overlap [from, to).
+func (s *LocationService) dwellSamples(ctx context.Context, airport, checkpoint string, from, to time.Time) ([]float64, error) {
+	pipe := s.Redis.Client.Pipeline()
+	var cmds []*redis.StringSliceCmd
+	for b := from.Truncate(dwellBucket); b.Before(to); b = b.Add(dwellBucket) {
+		cmds = append(cmds, pipe.LRange(ctx, dwellKey(airport, checkpoint, b), 0, -1))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	var samples []float64
+	for _, cmd := range cmds {
+		values, _ := cmd.Result()
+		for _, v := range values {
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				samples = append(samples, n)
+			}
+		}
+	}
+	return samples, nil
+}
+
+// summarizeDwell reports the median dwell, which shrugs off the traveler
+// who stopped for coffee before security.
+func summarizeDwell(checkpoint string, samples []float64) models.CheckpointWait {
+	wait := models.CheckpointWait{Checkpoint: checkpoint, SampleCount: len(samples)}
+	if len(samples) == 0 {
+		return wait
+	}
+
+	wait.WaitMinutes = int(math.Round(median(samples) / 60))
+	wait.Confidence = dwellConfidence(len(samples))
+	return wait
+}
+
+func median(samples []float64) float64 {
+	sorted := slices.Clone(samples)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+func dwellConfidence(samples int) float64 {
+	c := float64(samples) / float64(samples+dwellPrior)
+	return math.Round(c*100) / 100
+}
+
+// blendWait weights the crowd median against the airport's published
+// average by confidence, so a single sample cannot swing the estimate.
+func blendWait(average int, crowd models.CheckpointWait) int {
+	switch {
+	case crowd.SampleCount == 0:
+		return average
+	case average <= 0:
+		return crowd.WaitMinutes
+	}
+	c := float64(crowd.SampleCount) / float64(crowd.SampleCount+dwellPrior)
+	return int(math.Round(c*float64(crowd.WaitMinutes) + (1-c)*float64(average)))
+}
+
+func dwellKey(airport, checkpoint string, bucket time.Time) string {
+	return fmt.Sprintf("airport:dwell:%s:%s:%d", airport, checkpoint, bucket.Unix())
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/terminal"
+)
+
+// TravelTimeProvider estimates how long it takes to get from a point to the
+// airport by road. Implementations can wrap a routing API; SpeedModel is
+// the offline default.
+type TravelTimeProvider interface {
+	TravelTime(ctx context.Context, fromLat, fromLon, toLat, toLon float64) (time.Duration, error)
+}
+
+// SpeedModel estimates road travel from straight-line distance: a detour
+// factor for the road network, city speed for the first stretch and
+// highway speed beyond it, plus a fixed overhead for parking or drop-off.
+type SpeedModel struct {
+	DetourFactor    float64
+	CityDistanceKm  float64
+	CitySpeedKmh    float64
+	HighwaySpeedKmh float64
+	Overhead        time.Duration
+}
+
+func NewSpeedModel() *SpeedModel {
+	return &SpeedModel{
+		DetourFactor:    1.3,
+		CityDistanceKm:  10,
+		CitySpeedKmh:    25,
+		HighwaySpeedKmh: 70,
+		Overhead:        10 * time.Minute,
+	}
+}
+
+func (m *SpeedModel) TravelTime(ctx context.Context, fromLat, fromLon, toLat, toLon float64) (time.Duration, error) {
+	km := terminal.Distance(fromLat, fromLon, toLat, toLon) / 1000 * m.DetourFactor
+
+	city := min(km, m.CityDistanceKm)
+	highway := km - city
+
+	hours := city/m.CitySpeedKmh + highway/m.HighwaySpeedKmh
+	return time.Duration(hours*float64(time.Hour)) + m.Overhead, nil
+}
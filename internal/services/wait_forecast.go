@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// forecastAlpha weights each hour's observed median against the
+	// smoothed value already in its hour-of-week slot.
+	forecastAlpha = 0.3
+	// forecastPrior is the observation count at which the model and the
+	// airport's average weigh equally.
+	forecastPrior = 3
+	// forecastOverall is the pseudo-checkpoint holding the airport-wide model.
+	forecastOverall = "all"
+	// forecastRollupKey records the last hour folded into the model, so
+	// hours missed while no replica was running are caught up.
+	forecastRollupKey = "forecast:rollup:through"
+)
+
+// Forecast sources
+const (
+	WaitSourceForecast = "forecast"
+)
+
+// ForecastSecurityWait predicts the security wait at an airport for a
+// future time from its hour-of-week model.
+func (s *LocationService) ForecastSecurityWait(ctx context.Context, airportCode string, at time.Time) (*models.SecurityWaitForecast, error) {
+	var airport models.Airport
+	if err := s.MongoDB.Airports().FindOne(ctx, bson.M{"code": airportCode}).Decode(&airport); err != nil {
+		return nil, err
+	}
+	return s.forecastWait(ctx, &airport, at)
+}
+
+// forecastWait reads the hour-of-week slot for at in the airport's local
+// time, treating holidays as their "like" weekday and scaling by their
+// factor, and blends it with the airport's average by confidence.
+func (s *LocationService) forecastWait(ctx context.Context, airport *models.Airport, at time.Time) (*models.SecurityWaitForecast, error) {
+	local := at.In(airportLocation(airport))
+	weekday, factor := local.Weekday(), 1.0
+
+	forecast := &models.SecurityWaitForecast{
+		AirportCode: airport.Code,
+		At:          at,
+		Source:      WaitSourceAverage,
+	}
+
+	if h, ok := s.Holidays.Lookup(airport.Code, local); ok {
+		forecast.Holiday = h.Name
+		if d, ok := parseWeekday(h.Like); ok {
+			weekday = d
+		}
+		factor = h.Factor
+	}
+
+	model, err := s.waitModel(ctx, airport.Code, hourOfWeek(weekday, local.Hour()))
+	if err != nil {
+		return nil, err
+	}
+
+	average := float64(airport.SecurityWaitAvg) * factor
+	forecast.WaitMinutes = int(math.Round(average))
+
+	for _, name := range slices.Sorted(maps.Keys(model)) {
+		slot := model[name]
+		wait := models.CheckpointWait{
+			Checkpoint:  name,
+			WaitMinutes: blendForecast(average, slot.minutes*factor, slot.observations),
+			Confidence:  forecastConfidence(slot.observations),
+			SampleCount: slot.observations,
+		}
+
+		if name == forecastOverall {
+			forecast.WaitMinutes = wait.WaitMinutes
+			forecast.Confidence = wait.Confidence
+			forecast.Observations = wait.SampleCount
+			forecast.Source = WaitSourceForecast
+			continue
+		}
+		forecast.Checkpoints = append(forecast.Checkpoints, wait)
+	}
+
+	return forecast, nil
+}
+
+// forecastSlot is one smoothed hour-of-week value.
+type forecastSlot struct {
+	minutes      float64
+	observations int
+}
+
+// waitModel returns the smoothed slot for every checkpoint at an
+// hour of week.
+func (s *LocationService) waitModel(ctx context.Context, airport string, slot int) (map[string]forecastSlot, error) {
+	key := forecastKey(airport)
+	values, err := s.Redis.Client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.Redis.Client.HGetAll(ctx, key+":n").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := strconv.Itoa(slot) + ":"
+	model := make(map[string]forecastSlot)
+	for field, v := range values {
+		checkpoint, ok := strings.CutPrefix(field, prefix)
+		if !ok {
+			continue
+		}
+		minutes, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			continue
+		}
+		n, _ := strconv.Atoi(counts[field])
+		model[checkpoint] = forecastSlot{minutes: minutes, observations: n}
+	}
+	return model, nil
+}
+
+// StartForecastService folds each completed hour's dwell samples and
+// official readings into the hour-of-week model.
+func (s *LocationService) StartForecastService(ctx context.Context) {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	log.Println("📈 Started security wait forecast service")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping forecast service")
+			return
+		case <-ticker.C:
+			s.rollupPending(ctx, time.Now())
+		}
+	}
+}
+
+// rollupPending rolls up every completed hour since the last one rolled up,
+// going back no further than samples are retained.
+func (s *LocationService) rollupPending(ctx context.Context, now time.Time) {
+	latest := now.Truncate(time.Hour).Add(-time.Hour)
+	from := latest
+
+	if through, err := s.Redis.Client.Get(ctx, forecastRollupKey).Int64(); err == nil {
+		from = time.Unix(through, 0).Add(time.Hour)
+		if oldest := now.Add(-dwellRetention).Truncate(time.Hour).Add(time.Hour); from.Before(oldest) {
+			from = oldest
+		}
+	}
+
+	for hour := from; !hour.After(latest); hour = hour.Add(time.Hour) {
+		s.rollupHour(ctx, hour)
+	}
+	if !from.After(latest) {
+		s.Redis.Client.Set(ctx, forecastRollupKey, latest.Unix(), 0)
+	}
+}
+
+// rollupHour smooths the median of one hour's dwell samples and official
+// readings into its slot, once across all replicas. Holidays are skipped so
+// they do not skew the regular week.
+func (s *LocationService) rollupHour(ctx context.Context, hour time.Time) {
+	lock := fmt.Sprintf("forecast:rollup:%d", hour.Unix())
+	first, err := s.Redis.Client.SetNX(ctx, lock, 1, dwellRetention+time.Hour).Result()
+	if err != nil || !first {
+		return
+	}
+
+	codes, err := s.Redis.Client.SUnion(ctx, dwellAirportsKey, officialAirportsKey).Result()
+	if err != nil {
+		log.Printf("Error listing airports for forecast rollup: %v", err)
+		return
+	}
+
+	for _, code := range codes {
+		var airport models.Airport
+		if err := s.MongoDB.Airports().FindOne(ctx, bson.M{"code": code}).Decode(&airport); err != nil {
+			airport = models.Airport{Code: code}
+		}
+
+		local := hour.In(airportLocation(&airport))
+		if _, ok := s.Holidays.Lookup(code, local); ok {
+			continue
+		}
+		slot := hourOfWeek(local.Weekday(), local.Hour())
+
+		checkpoints, err := s.Redis.Client.SUnion(ctx, fmt.Sprintf("airport:checkpoints:%s", code), officialCheckpointsKey(code)).Result()
+		if err != nil {
+			continue
+		}
+
+		var all []float64
+		for _, checkpoint := range checkpoints {
+			samples, err := s.hourSamples(ctx, code, checkpoint, hour)
+			if err != nil || len(samples) == 0 {
+				continue
+			}
+			all = append(all, samples...)
+			s.smoothSlot(ctx, code, checkpoint, slot, median(samples))
+		}
+		if len(all) > 0 {
+			s.smoothSlot(ctx, code, forecastOverall, slot, median(all))
+		}
+	}
+}
+
+// hourSamples returns a checkpoint's crowd dwells and official readings in
+// an hour, in minutes.
+func (s *LocationService) hourSamples(ctx context.Context, airport, checkpoint string, hour time.Time) ([]float64, error) {
+	dwells, err := s.dwellSamples(ctx, airport, checkpoint, hour, hour.Add(time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	readings, err := s.Redis.Client.HVals(ctx, officialSampleKey(airport, checkpoint, hour)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]float64, 0, len(dwells)+len(readings))
+	for _, seconds := range dwells {
+		samples = append(samples, seconds/60)
+	}
+	for _, v := range readings {
+		if minutes, err := strconv.ParseFloat(v, 64); err == nil {
+			samples = append(samples, minutes)
+		}
+	}
+	return samples, nil
+}
+
+func (s *LocationService) smoothSlot(ctx context.Context, airport, checkpoint string, slot int, minutes float64) {
+	key := forecastKey(airport)
+	field := fmt.Sprintf("%d:%s", slot, checkpoint)
+
+	if v, err := s.Redis.Client.HGet(ctx, key, field).Float64(); err == nil {
+		minutes = forecastAlpha*minutes + (1-forecastAlpha)*v
+	}
+
+	pipe := s.Redis.Client.TxPipeline()
+	pipe.HSet(ctx, key, field, strconv.FormatFloat(minutes, 'f', 2, 64))
+	pipe.HIncrBy(ctx, key+":n", field, 1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Error updating wait forecast for %s: %v", airport, err)
+	}
+}
+
+func blendForecast(average, modeled float64, observations int) int {
+	if average <= 0 {
+		return int(math.Round(modeled))
+	}
+	c := float64(observations) / float64(observations+forecastPrior)
+	return int(math.Round(c*modeled + (1-c)*average))
+}
+
+func forecastConfidence(observations int) float64 {
+	c := float64(observations) / float64(observations+forecastPrior)
+	return math.Round(c*100) / 100
+}
+
+// hourOfWeek numbers the week's hours from Sunday 00:00 (0) to Saturday
+// 23:00 (167).
+func hourOfWeek(day time.Weekday, hour int) int {
+	return int(day)*24 + hour
+}
+
+func airportLocation(airport *models.Airport) *time.Location {
+	if airport.Timezone != "" {
+		if loc, err := time.LoadLocation(airport.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+func forecastKey(airport string) string {
+	return fmt.Sprintf("airport:forecast:%s", strings.ToUpper(airport))
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/database"
+	"github.com/onoja123/travel-companion-backend/internal/models"
+	"github.com/onoja123/travel-companion-backend/internal/waittime"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// officialMaxAge drops published waits older than this.
+	officialMaxAge = time.Hour
+	// defaultIngestInterval is used when no interval is configured.
+	defaultIngestInterval = 10 * time.Minute
+	// officialAirportsKey is the set of airports that have ever had an
+	// official reading.
+	officialAirportsKey = "official:airports"
+)
+
+// WaitSourceOfficial marks waits published by the airport or TSA.
+const WaitSourceOfficial = "official"
+
+// WaitTimeIngestor fetches official wait times on a schedule and stores
+// them where GetSecurityWaitTime reads them.
+type WaitTimeIngestor struct {
+	MongoDB  *database.MongoDB
+	Redis    *database.RedisClient
+	Sources  []waittime.Source
+	Interval time.Duration
+}
+
+func NewWaitTimeIngestor(db *database.MongoDB, redis *database.RedisClient, sources []waittime.Source, interval time.Duration) *WaitTimeIngestor {
+	if interval <= 0 {
+		interval = defaultIngestInterval
+	}
+	return &WaitTimeIngestor{
+		MongoDB:  db,
+		Redis:    redis,
+		Sources:  sources,
+		Interval: interval,
+	}
+}
+
+func (w *WaitTimeIngestor) Start(ctx context.Context) {
+	if len(w.Sources) == 0 {
+		log.Println("No wait time feeds configured")
+		return
+	}
+
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	log.Printf("⏱️ Started wait time ingestion (%d sources)", len(w.Sources))
+	w.Ingest(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Stopping wait time ingestion")
+			return
+		case <-ticker.C:
+			w.Ingest(ctx)
+		}
+	}
+}
+
+// Ingest fetches every source once per interval across replicas, keeps the
+// newest fresh report per checkpoint and writes one reading per airport.
+func (w *WaitTimeIngestor) Ingest(ctx context.Context) {
+	lock := fmt.Sprintf("waittime:ingest:%d", time.Now().Truncate(w.Interval).Unix())
+	first, err := w.Redis.Client.SetNX(ctx, lock, 1, w.Interval).Result()
+	if err != nil || !first {
+		return
+	}
+
+	airports, err := w.trackedAirports(ctx)
+	if err != nil {
+		log.Printf("Error listing airports for wait times: %v", err)
+		return
+	}
+
+	latest := make(map[string]map[string]waittime.Observation) // airport -> checkpoint -> report
+	for _, source := range w.Sources {
+		observations, err := source.Fetch(ctx, airports)
+		if err != nil {
+			log.Printf("Error fetching wait times from %s: %v", source.Name(), err)
+			continue
+		}
+
+		for _, obs := range observations {
+			if time.Since(obs.ObservedAt) > officialMaxAge {
+				continue
+			}
+			code := strings.ToUpper(obs.Airport)
+			if latest[code] == nil {
+				latest[code] = make(map[string]waittime.Observation)
+			}
+			if prev, ok := latest[code][obs.Checkpoint]; !ok || obs.ObservedAt.After(prev.ObservedAt) {
+				latest[code][obs.Checkpoint] = obs
+			}
+		}
+	}
+
+	for code, checkpoints := range latest {
+		if err := w.store(ctx, code, checkpoints); err != nil {
+			log.Printf("Error storing wait times for %s: %v", code, err)
+		}
+	}
+}
+
+// trackedAirports returns the departure airports of active tracked flights.
+func (w *WaitTimeIngestor) trackedAirports(ctx context.Context) ([]models.Airport, error) {
+	codes, err := w.MongoDB.TrackedFlights().Distinct(ctx, "departure_airport", bson.M{"is_active": true})
+	if err != nil {
+		return nil, err
+	}
+	if len(codes) == 0 {
+		return nil, nil
+	}
+
+	cursor, err := w.MongoDB.Airports().Find(ctx, bson.M{"code": bson.M{"$in": codes}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var airports []models.Airport
+	if err := cursor.All(ctx, &airports); err != nil {
+		return nil, err
+	}
+	return airports, nil
+}
+
+// store writes an airport's official reading to its own key, which
+// GetSecurityWaitTime blends crowd samples against, and clears the blended
+// wait time cache so the next read picks it up. Each checkpoint's report is also kept by
+// the hour it was observed in for the forecast model.
+func (w *WaitTimeIngestor) store(ctx context.Context, code string, checkpoints map[string]waittime.Observation) error {
+	wait := models.SecurityWaitTime{
+		AirportCode: code,
+		Source:      WaitSourceOfficial,
+		Confidence:  1,
+	}
+
+	var total int
+	var observed time.Time
+	for _, name := range slices.Sorted(maps.Keys(checkpoints)) {
+		obs := checkpoints[name]
+		total += obs.WaitMinutes
+		if obs.ObservedAt.After(observed) {
+			observed = obs.ObservedAt
+		}
+		wait.Checkpoints = append(wait.Checkpoints, models.CheckpointWait{
+			Checkpoint:  name,
+			WaitMinutes: obs.WaitMinutes,
+			Confidence:  1,
+		})
+	}
+	wait.CurrentWaitTime = int(math.Round(float64(total) / float64(len(checkpoints))))
+	wait.Timestamp = observed.Format(time.RFC3339)
+
+	data, err := json.Marshal(wait)
+	if err != nil {
+		return err
+	}
+
+	pipe := w.Redis.Client.TxPipeline()
+	pipe.Set(ctx, officialWaitKey(code), data, 3*w.Interval)
+	pipe.Del(ctx, fmt.Sprintf("airport:wait:%s", code))
+	for name, obs := range checkpoints {
+		key := officialSampleKey(code, name, obs.ObservedAt.Truncate(time.Hour))
+		pipe.HSet(ctx, key, obs.ObservedAt.Unix(), obs.WaitMinutes)
+		pipe.Expire(ctx, key, dwellRetention)
+		pipe.SAdd(ctx, officialCheckpointsKey(code), name)
+		pipe.Expire(ctx, officialCheckpointsKey(code), dwellRetention)
+	}
+	pipe.SAdd(ctx, officialAirportsKey, code)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func officialWaitKey(code string) string {
+	return fmt.Sprintf("airport:wait:official:%s", strings.ToUpper(code))
+}
+
+// officialSampleKey holds one checkpoint's official readings in an hour,
+// keyed by when each was observed so a report fetched twice counts once.
+func officialSampleKey(code, checkpoint string, hour time.Time) string {
+	return fmt.Sprintf("airport:official:%s:%s:%d", strings.ToUpper(code), checkpoint, hour.Unix())
+}
+
+func officialCheckpointsKey(code string) string {
+	return fmt.Sprintf("airport:official:checkpoints:%s", strings.ToUpper(code))
+}
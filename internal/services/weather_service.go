@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/database"
+	"github.com/onoja123/travel-companion-backend/internal/models"
+	"github.com/onoja123/travel-companion-backend/internal/weather"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrNoWeatherStation is returned for airports without an ICAO code, which
+// METARs and TAFs are filed under.
+var ErrNoWeatherStation = errors.New("airport has no weather station")
+
+// ErrWeatherUnavailable is returned while a recent fetch for a station has
+// failed, rather than trying the source again.
+var ErrWeatherUnavailable = errors.New("weather source unavailable")
+
+// defaultWeatherCacheTTL is used when no cache TTL is configured. METARs
+// are issued hourly, TAFs every six hours.
+const defaultWeatherCacheTTL = 10 * time.Minute
+
+const (
+	// weatherFailureTTL is how long a failed fetch is remembered, so an
+	// outage does not cost every request a timeout.
+	weatherFailureTTL = time.Minute
+	// flightWeatherTimeout bounds the weather lookups of a flight status
+	// request; without an answer in time the risk is left out.
+	flightWeatherTimeout = 2 * time.Second
+)
+
+// WeatherService serves airport METARs and TAFs and the delay risk they
+// suggest. Raw reports are cached in Redis and parsed on each request.
+type WeatherService struct {
+	MongoDB  *database.MongoDB
+	Redis    *database.RedisClient
+	Source   weather.Source
+	CacheTTL time.Duration
+}
+
+func NewWeatherService(db *database.MongoDB, redis *database.RedisClient, source weather.Source, cacheTTL time.Duration) *WeatherService {
+	if cacheTTL <= 0 {
+		cacheTTL = defaultWeatherCacheTTL
+	}
+	return &WeatherService{
+		MongoDB:  db,
+		Redis:    redis,
+		Source:   source,
+		CacheTTL: cacheTTL,
+	}
+}
+
+// GetAirportWeather returns an airport's latest METAR and TAF and the delay
+// risk now.
+func (s *WeatherService) GetAirportWeather(ctx context.Context, airportCode string) (*models.AirportWeather, error) {
+	var airport models.Airport
+	err := s.MongoDB.Airports().FindOne(ctx, bson.M{"code": airportCode}).Decode(&airport)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrAirportNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if airport.ICAO == "" {
+		return nil, ErrNoWeatherStation
+	}
+
+	reports, err := s.reports(ctx, airport.ICAO)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := &models.AirportWeather{
+		AirportCode: airport.Code,
+		Station:     airport.ICAO,
+		Source:      s.Source.Name(),
+		FetchedAt:   now,
+	}
+	if reports.METAR != "" {
+		if result.METAR, err = weather.ParseMETAR(reports.METAR, now); err != nil {
+			log.Printf("Error parsing METAR for %s: %v", airport.ICAO, err)
+		}
+	}
+	if reports.TAF != "" {
+		if result.TAF, err = weather.ParseTAF(reports.TAF, now); err != nil {
+			log.Printf("Error parsing TAF for %s: %v", airport.ICAO, err)
+		}
+	}
+	result.DelayRisk = weather.RiskAt(airport.Code, result.METAR, result.TAF, now, now)
+
+	return result, nil
+}
+
+// DelayRisk returns the weather delay risk at an airport at a time, or nil
+// when there is no report covering it.
+func (s *WeatherService) DelayRisk(ctx context.Context, airportCode string, at time.Time) *models.DelayRisk {
+	w, err := s.GetAirportWeather(ctx, airportCode)
+	if err != nil {
+		if !errors.Is(err, weather.ErrNoReport) && !errors.Is(err, ErrNoWeatherStation) && !errors.Is(err, ErrAirportNotFound) && !errors.Is(err, ErrWeatherUnavailable) {
+			log.Printf("Error fetching weather for %s: %v", airportCode, err)
+		}
+		return nil
+	}
+	return weather.RiskAt(w.AirportCode, w.METAR, w.TAF, at, time.Now())
+}
+
+// FlightDelayRisk assesses the weather at departure and at arrival, at the
+// flight's expected times, giving up after flightWeatherTimeout.
+func (s *WeatherService) FlightDelayRisk(ctx context.Context, status *models.FlightStatus) *models.WeatherDelayRisk {
+	ctx, cancel := context.WithTimeout(ctx, flightWeatherTimeout)
+	defer cancel()
+
+	delay := time.Duration(status.DelayMinutes) * time.Minute
+	risk := &models.WeatherDelayRisk{Level: models.DelayRiskLow}
+
+	if status.DepartureAirport != "" && !status.DepartureTime.IsZero() {
+		risk.Departure = s.DelayRisk(ctx, status.DepartureAirport, status.DepartureTime.Add(delay))
+	}
+	if status.ArrivalAirport != "" && !status.ArrivalTime.IsZero() {
+		risk.Arrival = s.DelayRisk(ctx, status.ArrivalAirport, status.ArrivalTime.Add(delay))
+	}
+	if risk.Departure == nil && risk.Arrival == nil {
+		return nil
+	}
+
+	for _, r := range []*models.DelayRisk{risk.Departure, risk.Arrival} {
+		if r != nil {
+			risk.Level = weather.Higher(risk.Level, r.Level)
+		}
+	}
+	return risk
+}
+
+// reports returns a station's raw reports, from the cache when fresh. A
+// station without reports is cached too, as empty reports, and a failed
+// fetch briefly, since flight status requests ask for every airport's
+// weather.
+func (s *WeatherService) reports(ctx context.Context, station string) (weather.Reports, error) {
+	key := fmt.Sprintf("weather:%s", strings.ToUpper(station))
+	failedKey := key + ":failed"
+
+	if n, err := s.Redis.Client.Exists(ctx, failedKey).Result(); err == nil && n > 0 {
+		return weather.Reports{}, ErrWeatherUnavailable
+	}
+
+	var reports weather.Reports
+	if data, err := s.Redis.Client.Get(ctx, key).Bytes(); err == nil {
+		if err := json.Unmarshal(data, &reports); err == nil {
+			if reports.METAR == "" && reports.TAF == "" {
+				return weather.Reports{}, weather.ErrNoReport
+			}
+			return reports, nil
+		}
+	}
+
+	reports, err := s.Source.Fetch(ctx, station)
+	// Record the outcome even if the fetch used up ctx
+	cacheCtx := context.WithoutCancel(ctx)
+	switch {
+	case errors.Is(err, weather.ErrNoReport):
+		s.Redis.Client.Set(cacheCtx, key, "{}", s.CacheTTL)
+	case err != nil && !errors.Is(ctx.Err(), context.Canceled):
+		s.Redis.Client.Set(cacheCtx, failedKey, err.Error(), weatherFailureTTL)
+	}
+	if err != nil {
+		return weather.Reports{}, err
+	}
+
+	if data, err := json.Marshal(reports); err == nil {
+		s.Redis.Client.Set(cacheCtx, key, data, s.CacheTTL)
+	}
+	return reports, nil
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/onoja123/travel-companion-backend/internal/database"
+	"github.com/onoja123/travel-companion-backend/internal/weather"
+	"github.com/redis/go-redis/v9"
+)
+
+// countingSource has reports only for KJFK, fails for KDOWN and counts
+// fetches.
+type countingSource struct {
+	fetches int
+}
+
+func (s *countingSource) Name() string { return "counting" }
+
+func (s *countingSource) Fetch(_ context.Context, station string) (weather.Reports, error) {
+	s.fetches++
+	if station == "KDOWN" {
+		return weather.Reports{}, errors.New("service unavailable")
+	}
+	if station != "KJFK" {
+		return weather.Reports{}, weather.ErrNoReport
+	}
+	return weather.Reports{METAR: "KJFK 181751Z 28015KT 10SM FEW050 18/06 A2992"}, nil
+}
+
+func TestWeatherReportsCached(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	source := &countingSource{}
+	s := NewWeatherService(nil, &database.RedisClient{Client: client}, source, time.Minute)
+	ctx := context.Background()
+
+	for range 3 {
+		if _, err := s.reports(ctx, "KJFK"); err != nil {
+			t.Fatalf("reports: %v", err)
+		}
+		if _, err := s.reports(ctx, "EGLL"); !errors.Is(err, weather.ErrNoReport) {
+			t.Fatalf("reports without a report: %v, want ErrNoReport", err)
+		}
+	}
+	if source.fetches != 2 {
+		t.Errorf("fetched %d times, want once per station", source.fetches)
+	}
+
+	mr.FastForward(time.Minute)
+	s.reports(ctx, "EGLL")
+	if source.fetches != 3 {
+		t.Errorf("fetched %d times, want a refetch once the cache expires", source.fetches)
+	}
+}
+
+func TestWeatherFailuresCachedBriefly(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	source := &countingSource{}
+	s := NewWeatherService(nil, &database.RedisClient{Client: client}, source, time.Hour)
+	ctx := context.Background()
+
+	if _, err := s.reports(ctx, "KDOWN"); err == nil || errors.Is(err, ErrWeatherUnavailable) {
+		t.Fatalf("first fetch: %v, want the source's error", err)
+	}
+	if _, err := s.reports(ctx, "KDOWN"); !errors.Is(err, ErrWeatherUnavailable) {
+		t.Fatalf("second fetch: %v, want ErrWeatherUnavailable", err)
+	}
+	if source.fetches != 1 {
+		t.Errorf("fetched %d times, want once while the failure is cached", source.fetches)
+	}
+
+	mr.FastForward(weatherFailureTTL)
+	s.reports(ctx, "KDOWN")
+	if source.fetches != 2 {
+		t.Errorf("fetched %d times, want a retry after %v", source.fetches, weatherFailureTTL)
+	}
+}
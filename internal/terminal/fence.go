@@ -0,0 +1,124 @@
+package terminal
+
+import (
+	"fmt"
+	"math"
+)
+
+// Fence zones
+const (
+	ZoneAirport  = "airport"  // the whole airport site
+	ZoneTerminal = "terminal" // a terminal building, landside
+	ZoneAirside  = "airside"  // past security
+)
+
+// Fence is a geofence polygon. Points are [longitude, latitude] pairs, as
+// in GeoJSON; the ring may be open or closed.
+type Fence struct {
+	Zone     string       `json:"zone"`
+	Terminal string       `json:"terminal,omitempty"`
+	Polygon  [][2]float64 `json:"polygon"`
+
+	minLon, minLat, maxLon, maxLat float64
+}
+
+func (g *Graph) addFence(f Fence) error {
+	switch f.Zone {
+	case ZoneAirport, ZoneTerminal, ZoneAirside:
+	default:
+		return fmt.Errorf("unknown fence zone %q", f.Zone)
+	}
+	if len(f.Polygon) < 3 {
+		return fmt.Errorf("fence polygon needs at least 3 points")
+	}
+
+	f.minLon, f.minLat = math.Inf(1), math.Inf(1)
+	f.maxLon, f.maxLat = math.Inf(-1), math.Inf(-1)
+	for _, p := range f.Polygon {
+		f.minLon, f.maxLon = math.Min(f.minLon, p[0]), math.Max(f.maxLon, p[0])
+		f.minLat, f.maxLat = math.Min(f.minLat, p[1]), math.Max(f.maxLat, p[1])
+	}
+
+	g.fences = append(g.fences, f)
+	return nil
+}
+
+// Contains reports whether a coordinate is inside the fence, by ray casting.
+func (f *Fence) Contains(lat, lon float64) bool {
+	if lon < f.minLon || lon > f.maxLon || lat < f.minLat || lat > f.maxLat {
+		return false
+	}
+
+	inside := false
+	for i, j := 0, len(f.Polygon)-1; i < len(f.Polygon); j, i = i, i+1 {
+		xi, yi := f.Polygon[i][0], f.Polygon[i][1]
+		xj, yj := f.Polygon[j][0], f.Polygon[j][1]
+		if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Placement is where a coordinate falls among an airport's fences.
+type Placement struct {
+	Airport  string
+	Terminal string
+	Airside  bool
+}
+
+// Locate finds the airport whose fences contain a coordinate. Airside
+// fences take precedence over terminal and airport fences.
+func (r *Registry) Locate(lat, lon float64) (*Placement, bool) {
+	if r == nil {
+		return nil, false
+	}
+
+	for _, g := range r.graphs {
+		var found *Placement
+		for i := range g.fences {
+			f := &g.fences[i]
+			if !f.Contains(lat, lon) {
+				continue
+			}
+
+			if found == nil {
+				found = &Placement{Airport: g.Airport}
+			}
+			if f.Terminal != "" && (found.Terminal == "" || f.Zone == ZoneAirside) {
+				found.Terminal = f.Terminal
+			}
+			if f.Zone == ZoneAirside {
+				found.Airside = true
+			}
+		}
+		if found != nil {
+			return found, true
+		}
+	}
+
+	return nil, false
+}
+
+// HasFences reports whether an airport's extent is defined by polygons
+// rather than a radius around its reference point.
+func (r *Registry) HasFences(airport string) bool {
+	g, ok := r.Get(airport)
+	return ok && len(g.fences) > 0
+}
+
+func parsePolygon(coords [][][]float64) ([][2]float64, error) {
+	if len(coords) == 0 {
+		return nil, fmt.Errorf("empty polygon")
+	}
+
+	// Only the outer ring; holes are not used for terminal fences.
+	ring := make([][2]float64, 0, len(coords[0]))
+	for _, p := range coords[0] {
+		if len(p) < 2 {
+			return nil, fmt.Errorf("invalid polygon point")
+		}
+		ring = append(ring, [2]float64{p[0], p[1]})
+	}
+	return ring, nil
+}
@@ -0,0 +1,186 @@
+// Package terminal models airport terminals as walkable graphs: gates,
+// security checkpoints, entrances and transit stops joined by walkways,
+// escalators and trains, each with a traversal time. Polygon geofences
+// mark the airport site, terminal buildings and airside areas.
+package terminal
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// WalkingSpeed is the default walking pace in meters per second (~5 km/h).
+const WalkingSpeed = 1.4
+
+// Node types
+const (
+	NodeGate     = "gate"
+	NodeSecurity = "security"
+	NodeEntrance = "entrance"
+	NodeTransit  = "transit"
+	NodeJunction = "junction"
+)
+
+// Edge types
+const (
+	EdgeWalkway   = "walkway"
+	EdgeTrain     = "train"
+	EdgeSecurity  = "security"
+	EdgeEscalator = "escalator"
+	EdgeStairs    = "stairs"
+	EdgeElevator  = "elevator"
+)
+
+// Node is a point in the terminal.
+type Node struct {
+	ID        string  `json:"id"`
+	Type      string  `json:"type"`
+	Name      string  `json:"name,omitempty"`
+	Terminal  string  `json:"terminal,omitempty"`
+	Gate      string  `json:"gate,omitempty"` // gate label as shown on departure boards
+	Airside   bool    `json:"airside,omitempty"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Edge is a directed link between two nodes.
+type Edge struct {
+	From           string  `json:"from"`
+	To             string  `json:"to"`
+	Type           string  `json:"type,omitempty"`
+	DistanceMeters float64 `json:"distance_meters,omitempty"`
+	// Seconds is a fixed traversal time (trains, checkpoints). When zero,
+	// the edge is walked at the route's speed.
+	Seconds float64 `json:"seconds,omitempty"`
+}
+
+// Graph is one airport's terminal graph and geofences.
+type Graph struct {
+	Airport string
+	nodes   map[string]*Node
+	edges   map[string][]Edge
+	gates   map[string][]*Node // normalized gate label -> gate nodes
+	fences  []Fence
+}
+
+func newGraph(airport string) *Graph {
+	return &Graph{
+		Airport: strings.ToUpper(airport),
+		nodes:   make(map[string]*Node),
+		edges:   make(map[string][]Edge),
+		gates:   make(map[string][]*Node),
+	}
+}
+
+func (g *Graph) addNode(n Node) error {
+	if n.ID == "" {
+		return fmt.Errorf("node without id")
+	}
+	if _, ok := g.nodes[n.ID]; ok {
+		return fmt.Errorf("duplicate node %q", n.ID)
+	}
+	if n.Type == "" {
+		n.Type = NodeJunction
+	}
+
+	node := &n
+	g.nodes[n.ID] = node
+	if n.Gate != "" {
+		key := NormalizeGate(n.Gate)
+		g.gates[key] = append(g.gates[key], node)
+	}
+	return nil
+}
+
+// addEdge links two existing nodes, in both directions unless oneWay.
+// Missing distances are taken from the node coordinates.
+func (g *Graph) addEdge(e Edge, oneWay bool) error {
+	from, ok := g.nodes[e.From]
+	if !ok {
+		return fmt.Errorf("edge from unknown node %q", e.From)
+	}
+	to, ok := g.nodes[e.To]
+	if !ok {
+		return fmt.Errorf("edge to unknown node %q", e.To)
+	}
+	if e.Type == "" {
+		e.Type = EdgeWalkway
+	}
+	if e.DistanceMeters == 0 {
+		e.DistanceMeters = Distance(from.Latitude, from.Longitude, to.Latitude, to.Longitude)
+	}
+
+	g.edges[e.From] = append(g.edges[e.From], e)
+	if !oneWay {
+		e.From, e.To = e.To, e.From
+		g.edges[e.From] = append(g.edges[e.From], e)
+	}
+	return nil
+}
+
+// Node returns a node by ID.
+func (g *Graph) Node(id string) (*Node, bool) {
+	n, ok := g.nodes[id]
+	return n, ok
+}
+
+// Nodes returns every node in the graph.
+func (g *Graph) Nodes() []*Node {
+	nodes := make([]*Node, 0, len(g.nodes))
+	for _, n := range g.nodes {
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
+// GateNode finds the node for a gate label such as "B22" or "Gate B22".
+// When several terminals share a label, the one in terminal wins.
+func (g *Graph) GateNode(gate, terminal string) (*Node, bool) {
+	candidates := g.gates[NormalizeGate(gate)]
+	if len(candidates) == 0 {
+		return nil, false
+	}
+	for _, n := range candidates {
+		if terminal != "" && strings.EqualFold(n.Terminal, terminal) {
+			return n, true
+		}
+	}
+	return candidates[0], true
+}
+
+// NearestNode returns the node closest to a coordinate and its distance in
+// meters.
+func (g *Graph) NearestNode(lat, lon float64) (*Node, float64) {
+	var nearest *Node
+	best := math.Inf(1)
+	for _, n := range g.nodes {
+		if d := Distance(lat, lon, n.Latitude, n.Longitude); d < best {
+			nearest, best = n, d
+		}
+	}
+	return nearest, best
+}
+
+// Distance is the haversine distance between two coordinates in meters.
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadius = 6371000 // meters
+
+	lat1Rad := lat1 * math.Pi / 180
+	lat2Rad := lat2 * math.Pi / 180
+	deltaLat := (lat2 - lat1) * math.Pi / 180
+	deltaLon := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
+		math.Cos(lat1Rad)*math.Cos(lat2Rad)*
+			math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
+
+	return earthRadius * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// NormalizeGate turns a gate label into the form gates are matched by:
+// upper case, without spaces or a "Gate" prefix.
+func NormalizeGate(gate string) string {
+	gate = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(gate), " ", ""))
+	return strings.TrimPrefix(gate, "GATE")
+}
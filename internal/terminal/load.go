@@ -0,0 +1,249 @@
+package terminal
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Registry holds the terminal graphs of every airport that has one.
+type Registry struct {
+	graphs map[string]*Graph
+}
+
+func NewRegistry() *Registry {
+	return &Registry{graphs: make(map[string]*Graph)}
+}
+
+// Get returns the graph for an IATA airport code.
+func (r *Registry) Get(airport string) (*Graph, bool) {
+	if r == nil {
+		return nil, false
+	}
+	g, ok := r.graphs[strings.ToUpper(airport)]
+	return g, ok
+}
+
+// Add registers a graph, replacing any previous graph for its airport.
+func (r *Registry) Add(g *Graph) {
+	r.graphs[g.Airport] = g
+}
+
+// LoadDir loads every .json and .geojson file in dir. A missing directory
+// yields an empty registry.
+func LoadDir(dir string) (*Registry, error) {
+	r := NewRegistry()
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terminal graphs: %w", err)
+	}
+
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".json" && ext != ".geojson") {
+			continue
+		}
+
+		g, err := LoadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		r.Add(g)
+		log.Printf("Loaded terminal graph for %s (%d nodes, %d fences)", g.Airport, len(g.nodes), len(g.fences))
+	}
+
+	return r, nil
+}
+
+// LoadFile loads a graph from JSON or GeoJSON. The airport code comes from
+// the file's "airport" member, or else its name (e.g. JFK.geojson).
+//
+// JSON:
+//
+//	{
+//	  "airport": "JFK",
+//	  "nodes": [{"id": "B22", "type": "gate", "gate": "B22", "terminal": "4", "latitude": 40.64, "longitude": -73.78}],
+//	  "edges": [{"from": "SEC-4", "to": "B22", "type": "walkway"},
+//	            {"from": "AT-4", "to": "AT-5", "type": "train", "seconds": 120, "one_way": true}],
+//	  "fences": [{"zone": "airside", "terminal": "4", "polygon": [[-73.79, 40.64], [-73.78, 40.64], [-73.78, 40.65]]}]
+//	}
+//
+// GeoJSON: a FeatureCollection where Point features are nodes, LineString
+// features are edges and Polygon features are fences; properties carry the
+// same fields, and edge endpoints are named by "from" and "to".
+func LoadFile(path string) (*Graph, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+
+	var g *Graph
+	if strings.EqualFold(filepath.Ext(path), ".geojson") {
+		g, err = parseGeoJSON(raw, name)
+	} else {
+		g, err = parseJSON(raw, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid terminal graph %s: %w", path, err)
+	}
+
+	return g, nil
+}
+
+type graphFile struct {
+	Airport string     `json:"airport"`
+	Nodes   []Node     `json:"nodes"`
+	Edges   []edgeFile `json:"edges"`
+	Fences  []Fence    `json:"fences"`
+}
+
+type edgeFile struct {
+	Edge
+	OneWay bool `json:"one_way,omitempty"`
+}
+
+func parseJSON(raw []byte, defaultAirport string) (*Graph, error) {
+	var file graphFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, err
+	}
+
+	if file.Airport == "" {
+		file.Airport = defaultAirport
+	}
+	g := newGraph(file.Airport)
+
+	for _, n := range file.Nodes {
+		if err := g.addNode(n); err != nil {
+			return nil, err
+		}
+	}
+	for _, e := range file.Edges {
+		if err := g.addEdge(e.Edge, e.OneWay); err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range file.Fences {
+		if err := g.addFence(f); err != nil {
+			return nil, err
+		}
+	}
+
+	return g, nil
+}
+
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Airport  string    `json:"airport"`
+	Features []feature `json:"features"`
+}
+
+type feature struct {
+	Geometry struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+	Properties json.RawMessage `json:"properties"`
+}
+
+func parseGeoJSON(raw []byte, defaultAirport string) (*Graph, error) {
+	var fc featureCollection
+	if err := json.Unmarshal(raw, &fc); err != nil {
+		return nil, err
+	}
+	if fc.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("expected a FeatureCollection, got %q", fc.Type)
+	}
+
+	if fc.Airport == "" {
+		fc.Airport = defaultAirport
+	}
+	g := newGraph(fc.Airport)
+
+	// Nodes first so edges can reference them in any order.
+	var edges []edgeFile
+	for i, f := range fc.Features {
+		switch f.Geometry.Type {
+		case "Point":
+			var n Node
+			if err := json.Unmarshal(f.Properties, &n); err != nil {
+				return nil, fmt.Errorf("feature %d: %w", i, err)
+			}
+
+			var coords []float64 // GeoJSON order: longitude, latitude
+			if err := json.Unmarshal(f.Geometry.Coordinates, &coords); err != nil || len(coords) < 2 {
+				return nil, fmt.Errorf("feature %d: invalid point", i)
+			}
+			n.Longitude, n.Latitude = coords[0], coords[1]
+
+			if err := g.addNode(n); err != nil {
+				return nil, fmt.Errorf("feature %d: %w", i, err)
+			}
+
+		case "LineString":
+			var e edgeFile
+			if err := json.Unmarshal(f.Properties, &e); err != nil {
+				return nil, fmt.Errorf("feature %d: %w", i, err)
+			}
+
+			// The drawn line is usually longer than the straight hop
+			// between its endpoints.
+			if e.DistanceMeters == 0 {
+				var coords [][]float64
+				if err := json.Unmarshal(f.Geometry.Coordinates, &coords); err == nil {
+					e.DistanceMeters = lineLength(coords)
+				}
+			}
+			edges = append(edges, e)
+
+		case "Polygon":
+			var fence Fence
+			if err := json.Unmarshal(f.Properties, &fence); err != nil {
+				return nil, fmt.Errorf("feature %d: %w", i, err)
+			}
+
+			var coords [][][]float64
+			if err := json.Unmarshal(f.Geometry.Coordinates, &coords); err != nil {
+				return nil, fmt.Errorf("feature %d: invalid polygon", i)
+			}
+			polygon, err := parsePolygon(coords)
+			if err != nil {
+				return nil, fmt.Errorf("feature %d: %w", i, err)
+			}
+			fence.Polygon = polygon
+
+			if err := g.addFence(fence); err != nil {
+				return nil, fmt.Errorf("feature %d: %w", i, err)
+			}
+		}
+	}
+
+	for i, e := range edges {
+		if err := g.addEdge(e.Edge, e.OneWay); err != nil {
+			return nil, fmt.Errorf("edge %d: %w", i, err)
+		}
+	}
+
+	return g, nil
+}
+
+func lineLength(coords [][]float64) float64 {
+	var total float64
+	for i := 1; i < len(coords); i++ {
+		a, b := coords[i-1], coords[i]
+		if len(a) < 2 || len(b) < 2 {
+			return 0
+		}
+		total += Distance(a[1], a[0], b[1], b[0])
+	}
+	return total
+}
@@ -0,0 +1,102 @@
+package terminal
+
+import (
+	"container/heap"
+	"fmt"
+	"slices"
+)
+
+// Route is a shortest path through the terminal.
+type Route struct {
+	Nodes          []*Node
+	Edges          []Edge
+	DistanceMeters float64
+	Seconds        float64
+}
+
+// Route finds the fastest path between two nodes with Dijkstra's algorithm,
+// walking at speed meters per second and never taking an edge whose type is
+// in avoid (stairs for a wheelchair, say).
+func (g *Graph) Route(fromID, toID string, speed float64, avoid ...string) (*Route, error) {
+	if _, ok := g.nodes[fromID]; !ok {
+		return nil, fmt.Errorf("unknown node %q", fromID)
+	}
+	if _, ok := g.nodes[toID]; !ok {
+		return nil, fmt.Errorf("unknown node %q", toID)
+	}
+	if speed <= 0 {
+		speed = WalkingSpeed
+	}
+
+	cost := map[string]float64{fromID: 0}
+	prev := make(map[string]Edge)
+	done := make(map[string]bool)
+
+	queue := &routeQueue{{node: fromID}}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(routeItem)
+		if done[item.node] {
+			continue
+		}
+		done[item.node] = true
+		if item.node == toID {
+			break
+		}
+
+		for _, e := range g.edges[item.node] {
+			if slices.Contains(avoid, e.Type) {
+				continue
+			}
+			next := item.cost + e.Traversal(speed)
+			if c, ok := cost[e.To]; !ok || next < c {
+				cost[e.To] = next
+				prev[e.To] = e
+				heap.Push(queue, routeItem{node: e.To, cost: next})
+			}
+		}
+	}
+
+	if !done[toID] {
+		return nil, fmt.Errorf("no route from %s to %s", fromID, toID)
+	}
+
+	route := &Route{Seconds: cost[toID]}
+	for id := toID; id != fromID; {
+		e := prev[id]
+		route.Edges = append([]Edge{e}, route.Edges...)
+		route.DistanceMeters += e.DistanceMeters
+		id = e.From
+	}
+	route.Nodes = append(route.Nodes, g.nodes[fromID])
+	for _, e := range route.Edges {
+		route.Nodes = append(route.Nodes, g.nodes[e.To])
+	}
+
+	return route, nil
+}
+
+// Traversal is the time to cross the edge in seconds.
+func (e Edge) Traversal(speed float64) float64 {
+	if e.Seconds > 0 {
+		return e.Seconds
+	}
+	return e.DistanceMeters / speed
+}
+
+type routeItem struct {
+	node string
+	cost float64
+}
+
+type routeQueue []routeItem
+
+func (q routeQueue) Len() int            { return len(q) }
+func (q routeQueue) Less(i, j int) bool  { return q[i].cost < q[j].cost }
+func (q routeQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *routeQueue) Push(x interface{}) { *q = append(*q, x.(routeItem)) }
+func (q *routeQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,164 @@
+package waittime
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/models"
+)
+
+// FeedSource reads one airport's own wait time feed, as CSV with a header
+// row or as JSON, decided by the Content-Type or the URL's extension:
+//
+//	checkpoint,wait_minutes,updated_at
+//	North,12,2026-10-18T16:15:00Z
+//
+//	[{"checkpoint": "North", "wait_minutes": 12, "updated_at": "2026-10-18T16:15:00Z"}]
+//
+// JSON may also wrap the list as {"checkpoints": [...]}. updated_at is
+// RFC 3339; rows without it are taken as current.
+type FeedSource struct {
+	Airport string
+	URL     string
+	Client  *http.Client
+}
+
+func NewFeedSource(airport, url string, client *http.Client) *FeedSource {
+	return &FeedSource{Airport: airport, URL: url, Client: client}
+}
+
+type feedRow struct {
+	Checkpoint  string  `json:"checkpoint"`
+	WaitMinutes float64 `json:"wait_minutes"`
+	UpdatedAt   string  `json:"updated_at"`
+}
+
+func (s *FeedSource) Name() string { return "feed:" + s.Airport }
+
+func (s *FeedSource) Fetch(ctx context.Context, _ []models.Airport) ([]Observation, error) {
+	resp, err := get(ctx, s.Client, s.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var rows []feedRow
+	if isCSV(resp.Header.Get("Content-Type"), s.URL) {
+		rows, err = parseCSV(resp.Body)
+	} else {
+		rows, err = parseJSON(resp.Body)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid feed for %s: %w", s.Airport, err)
+	}
+
+	now := time.Now()
+	observations := make([]Observation, 0, len(rows))
+	for _, row := range rows {
+		if row.WaitMinutes < 0 {
+			continue
+		}
+		obs := Observation{
+			Airport:     s.Airport,
+			Checkpoint:  strings.TrimSpace(row.Checkpoint),
+			WaitMinutes: int(math.Round(row.WaitMinutes)),
+			ObservedAt:  now,
+		}
+		if row.UpdatedAt != "" {
+			t, err := time.Parse(time.RFC3339, strings.TrimSpace(row.UpdatedAt))
+			if err != nil {
+				continue
+			}
+			obs.ObservedAt = t
+		}
+		observations = append(observations, obs)
+	}
+	return observations, nil
+}
+
+func isCSV(contentType, url string) bool {
+	if strings.Contains(contentType, "csv") {
+		return true
+	}
+	if strings.Contains(contentType, "json") {
+		return false
+	}
+	ext := path.Ext(strings.SplitN(url, "?", 2)[0])
+	return strings.EqualFold(ext, ".csv")
+}
+
+func parseJSON(r io.Reader) ([]feedRow, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []feedRow
+	if err := json.Unmarshal(data, &rows); err == nil {
+		return rows, nil
+	}
+
+	var wrapped struct {
+		Checkpoints []feedRow `json:"checkpoints"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		return nil, err
+	}
+	return wrapped.Checkpoints, nil
+}
+
+func parseCSV(r io.Reader) ([]feedRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	waitCol, ok := columns["wait_minutes"]
+	if !ok {
+		return nil, fmt.Errorf("missing wait_minutes column")
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	var rows []feedRow
+	for _, record := range records[1:] {
+		if waitCol >= len(record) {
+			continue
+		}
+		minutes, err := strconv.ParseFloat(strings.TrimSpace(record[waitCol]), 64)
+		if err != nil {
+			continue
+		}
+		rows = append(rows, feedRow{
+			Checkpoint:  field(record, "checkpoint"),
+			WaitMinutes: minutes,
+			UpdatedAt:   field(record, "updated_at"),
+		})
+	}
+	return rows, nil
+}
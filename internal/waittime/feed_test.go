@@ -0,0 +1,136 @@
+package waittime
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFeedSourceFetch(t *testing.T) {
+	updated := time.Date(2026, 10, 18, 16, 15, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		want        []Observation
+	}{
+		{
+			name:        "csv by content type",
+			path:        "/waits",
+			contentType: "text/csv; charset=utf-8",
+			body: "Checkpoint, Wait_Minutes, Updated_At\n" +
+				"North, 12.4, 2026-10-18T16:15:00Z\n" +
+				"South, closed, 2026-10-18T16:15:00Z\n" +
+				"East, -1, 2026-10-18T16:15:00Z\n" +
+				"West, 7, not a time\n",
+			want: []Observation{{Airport: "SEA", Checkpoint: "North", WaitMinutes: 12, ObservedAt: updated}},
+		},
+		{
+			name: "csv by extension",
+			path: "/waits.CSV?v=2",
+			body: "checkpoint,wait_minutes,updated_at\nNorth,12,2026-10-18T16:15:00Z\n",
+			want: []Observation{{Airport: "SEA", Checkpoint: "North", WaitMinutes: 12, ObservedAt: updated}},
+		},
+		{
+			name:        "ragged rows",
+			path:        "/waits.csv",
+			contentType: "text/csv",
+			body: "checkpoint,updated_at,wait_minutes\n" +
+				"North,2026-10-18T16:15:00Z,12,\n" +
+				"South,2026-10-18T16:15:00Z\n" +
+				"East,2026-10-18T16:15:00Z,8\n",
+			want: []Observation{
+				{Airport: "SEA", Checkpoint: "North", WaitMinutes: 12, ObservedAt: updated},
+				{Airport: "SEA", Checkpoint: "East", WaitMinutes: 8, ObservedAt: updated},
+			},
+		},
+		{
+			name:        "json list",
+			path:        "/waits.csv",
+			contentType: "application/json",
+			body:        `[{"checkpoint": " North ", "wait_minutes": 12.6, "updated_at": "2026-10-18T16:15:00Z"}]`,
+			want:        []Observation{{Airport: "SEA", Checkpoint: "North", WaitMinutes: 13, ObservedAt: updated}},
+		},
+		{
+			name: "wrapped json",
+			path: "/waits",
+			body: `{"checkpoints": [{"checkpoint": "North", "wait_minutes": 12, "updated_at": "2026-10-18T16:15:00Z"}]}`,
+			want: []Observation{{Airport: "SEA", Checkpoint: "North", WaitMinutes: 12, ObservedAt: updated}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			observations, err := NewFeedSource("SEA", server.URL+tt.path, server.Client()).Fetch(context.Background(), nil)
+			if err != nil {
+				t.Fatalf("Fetch: %v", err)
+			}
+			if len(observations) != len(tt.want) {
+				t.Fatalf("got %+v, want %+v", observations, tt.want)
+			}
+			for i, want := range tt.want {
+				got := observations[i]
+				if got.Airport != want.Airport || got.Checkpoint != want.Checkpoint || got.WaitMinutes != want.WaitMinutes || !got.ObservedAt.Equal(want.ObservedAt) {
+					t.Errorf("observation %d = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFeedSourceUndatedRowsAreCurrent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write([]byte("checkpoint,wait_minutes\nNorth,12\n"))
+	}))
+	defer server.Close()
+
+	before := time.Now()
+	observations, err := NewFeedSource("SEA", server.URL, server.Client()).Fetch(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(observations) != 1 || observations[0].ObservedAt.Before(before) {
+		t.Errorf("got %+v, want one observation stamped now", observations)
+	}
+}
+
+func TestFeedSourceErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+	}{
+		{name: "server error", status: http.StatusInternalServerError},
+		{name: "missing wait column", status: http.StatusOK, contentType: "text/csv", body: "checkpoint,minutes\nNorth,12\n"},
+		{name: "invalid json", status: http.StatusOK, contentType: "application/json", body: `{"checkpoints": "none"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			if _, err := NewFeedSource("SEA", server.URL, server.Client()).Fetch(context.Background(), nil); err == nil {
+				t.Error("Fetch succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+// Package waittime fetches security wait times from official feeds: the
+// TSA's per-checkpoint API and the CSV/JSON feeds some airports publish.
+package waittime
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/config"
+	"github.com/onoja123/travel-companion-backend/internal/models"
+)
+
+// Observation is one published wait at a checkpoint.
+type Observation struct {
+	Airport     string
+	Checkpoint  string
+	WaitMinutes int
+	ObservedAt  time.Time
+}
+
+// Source is a wait time feed. airports lists the airports worth fetching;
+// sources bound to a single airport's feed may ignore it.
+type Source interface {
+	Name() string
+	Fetch(ctx context.Context, airports []models.Airport) ([]Observation, error)
+}
+
+// NewSources builds a source for the TSA API, when configured, and one for
+// each airport feed.
+func NewSources(cfg *config.Config) ([]Source, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	var sources []Source
+	if cfg.WaitTimes.TSAURL != "" {
+		sources = append(sources, NewTSASource(cfg.WaitTimes.TSAURL, client))
+	}
+
+	for _, feed := range cfg.WaitTimes.Feeds {
+		airport, url, ok := strings.Cut(feed, "=")
+		if !ok || len(airport) != 3 || url == "" {
+			return nil, fmt.Errorf("invalid wait time feed %q, want CODE=URL", feed)
+		}
+		sources = append(sources, NewFeedSource(strings.ToUpper(airport), url, client))
+	}
+
+	return sources, nil
+}
+
+func get(ctx context.Context, client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,122 @@
+package waittime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/models"
+)
+
+// tsaBands maps the TSA's wait index to minutes. The API reports bands
+// (0 none, 1 up to 10 minutes, 2 up to 20, ...) rather than minutes, so each
+// maps to the middle of its band.
+var tsaBands = map[int]int{0: 0, 1: 5, 2: 15, 3: 25, 4: 38, 5: 50}
+
+// TSASource reads the TSA-style API: one request per airport,
+// ?ap=<code>&output=json, answering
+//
+//	{"WaitTimes": [{"CheckpointIndex": "3", "WaitTime": "2", "Created_Datetime": "10/18/2026 4:15:00 PM"}]}
+type TSASource struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewTSASource(url string, client *http.Client) *TSASource {
+	return &TSASource{URL: url, Client: client}
+}
+
+type tsaResponse struct {
+	WaitTimes []tsaWaitTime `json:"WaitTimes"`
+}
+
+type tsaWaitTime struct {
+	CheckpointIndex string `json:"CheckpointIndex"`
+	WaitTime        string `json:"WaitTime"`
+	Created         string `json:"Created_Datetime"`
+}
+
+func (s *TSASource) Name() string { return "tsa" }
+
+func (s *TSASource) Fetch(ctx context.Context, airports []models.Airport) ([]Observation, error) {
+	var observations []Observation
+	for _, airport := range airports {
+		obs, err := s.fetchAirport(ctx, airport)
+		if err != nil {
+			log.Printf("TSA wait times for %s: %v", airport.Code, err)
+			continue
+		}
+		observations = append(observations, obs...)
+	}
+	return observations, nil
+}
+
+// fetchAirport reads one airport's checkpoints. Report times are in the
+// airport's local time.
+func (s *TSASource) fetchAirport(ctx context.Context, airport models.Airport) ([]Observation, error) {
+	loc := time.UTC
+	if airport.Timezone != "" {
+		if l, err := time.LoadLocation(airport.Timezone); err == nil {
+			loc = l
+		}
+	}
+
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TSA URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("ap", airport.Code)
+	q.Set("output", "json")
+	u.RawQuery = q.Encode()
+
+	resp, err := get(ctx, s.Client, u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body tsaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid TSA response: %w", err)
+	}
+
+	// Keep the newest report per checkpoint.
+	latest := make(map[string]Observation)
+	for _, w := range body.WaitTimes {
+		band, err := strconv.Atoi(strings.TrimSpace(w.WaitTime))
+		if err != nil {
+			continue
+		}
+		minutes, ok := tsaBands[band]
+		if !ok {
+			minutes = tsaBands[5]
+		}
+		observed, err := time.ParseInLocation("1/2/2006 3:04:05 PM", strings.TrimSpace(w.Created), loc)
+		if err != nil {
+			continue
+		}
+
+		obs := Observation{
+			Airport:     airport.Code,
+			Checkpoint:  strings.TrimSpace(w.CheckpointIndex),
+			WaitMinutes: minutes,
+			ObservedAt:  observed,
+		}
+		if prev, ok := latest[obs.Checkpoint]; !ok || obs.ObservedAt.After(prev.ObservedAt) {
+			latest[obs.Checkpoint] = obs
+		}
+	}
+
+	observations := make([]Observation, 0, len(latest))
+	for _, obs := range latest {
+		observations = append(observations, obs)
+	}
+	return observations, nil
+}
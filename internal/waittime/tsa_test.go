@@ -0,0 +1,70 @@
+package waittime
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/models"
+)
+
+const tsaFixture = `{"WaitTimes": [
+	{"CheckpointIndex": "1", "WaitTime": "1", "Created_Datetime": "10/18/2026 3:50:00 PM"},
+	{"CheckpointIndex": "1", "WaitTime": "3", "Created_Datetime": "10/18/2026 4:15:00 PM"},
+	{"CheckpointIndex": "2", "WaitTime": "9", "Created_Datetime": "10/18/2026 4:05:00 PM"},
+	{"CheckpointIndex": "3", "WaitTime": "n/a", "Created_Datetime": "10/18/2026 4:05:00 PM"},
+	{"CheckpointIndex": "4", "WaitTime": "2", "Created_Datetime": "yesterday"}
+]}`
+
+func TestTSASourceFetch(t *testing.T) {
+	var queried []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queried = append(queried, r.URL.Query().Get("ap"))
+		if q := r.URL.Query(); q.Get("output") != "json" || q.Get("key") != "abc" {
+			t.Errorf("query %q, want output=json and the configured key", r.URL.RawQuery)
+		}
+		if r.URL.Query().Get("ap") != "JFK" {
+			http.Error(w, "unknown airport", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(tsaFixture))
+	}))
+	defer server.Close()
+
+	source := NewTSASource(server.URL+"/api?key=abc", server.Client())
+	airports := []models.Airport{
+		{Code: "JFK", Timezone: "America/New_York"},
+		{Code: "XXX"},
+	}
+
+	observations, err := source.Fetch(context.Background(), airports)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(queried) != 2 {
+		t.Errorf("queried %v, want one request per airport", queried)
+	}
+
+	sort.Slice(observations, func(i, j int) bool { return observations[i].Checkpoint < observations[j].Checkpoint })
+
+	newYork, _ := time.LoadLocation("America/New_York")
+	want := []Observation{
+		// Newest report per checkpoint, band 3 is 25 minutes
+		{Airport: "JFK", Checkpoint: "1", WaitMinutes: 25, ObservedAt: time.Date(2026, 10, 18, 16, 15, 0, 0, newYork)},
+		// Bands past the table count as the longest
+		{Airport: "JFK", Checkpoint: "2", WaitMinutes: 50, ObservedAt: time.Date(2026, 10, 18, 16, 5, 0, 0, newYork)},
+	}
+	if len(observations) != len(want) {
+		t.Fatalf("got %+v, want %+v", observations, want)
+	}
+	for i := range want {
+		got := observations[i]
+		if got.Airport != want[i].Airport || got.Checkpoint != want[i].Checkpoint || got.WaitMinutes != want[i].WaitMinutes || !got.ObservedAt.Equal(want[i].ObservedAt) {
+			t.Errorf("observation %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
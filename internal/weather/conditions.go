@@ -0,0 +1,214 @@
+// Package weather parses METAR observations and TAF forecasts, fetches them
+// from a Source and turns them into delay-risk hints.
+package weather
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/models"
+)
+
+// maxVisibility is reported for "9999", "P6SM", CAVOK and the like.
+const maxVisibility = 10000
+
+var (
+	windPattern       = regexp.MustCompile(`^(\d{3}|VRB)(\d{2,3})(?:G(\d{2,3}))?(KT|MPS|KMH)$`)
+	windVarPattern    = regexp.MustCompile(`^\d{3}V\d{3}$`)
+	metersPattern     = regexp.MustCompile(`^(\d{4})(?:NDV|[NSEW]{1,2})?$`)
+	statuteMiPattern  = regexp.MustCompile(`^([MP])?(\d+)?(?:/(\d+))?SM$`)
+	wholeMilesPattern = regexp.MustCompile(`^\d$`)
+	cloudPattern      = regexp.MustCompile(`^(FEW|SCT|BKN|OVC|VV)(\d{3}|///)(CB|TCU|///)?$`)
+	weatherPattern    = regexp.MustCompile(`^(\+|-|VC)?(MI|PR|BC|DR|BL|SH|TS|FZ)?((?:DZ|RA|SN|SG|IC|PL|GR|GS|UP|BR|FG|FU|VA|DU|SA|HZ|PY|PO|SQ|FC|SS|DS)*)$`)
+)
+
+// parseGroup reads one token of weather into c, returning false when the
+// token isn't a wind, visibility, cloud or weather group. next is the
+// following token, for US visibilities such as "1 1/2SM"; consumed
+// reports whether it was used.
+func parseGroup(c *models.Conditions, token, next string) (ok, consumed bool) {
+	switch token {
+	case "CAVOK":
+		c.VisibilityM = intPtr(maxVisibility)
+		c.SkyClear = true
+		c.Clouds = nil
+		c.CeilingFt = nil
+		c.NoWeather = true
+		return true, false
+	case "SKC", "CLR", "NSC", "NCD":
+		c.SkyClear = true
+		return true, false
+	case "NSW":
+		c.NoWeather = true
+		return true, false
+	}
+
+	if m := windPattern.FindStringSubmatch(token); m != nil {
+		c.Wind = parseWind(m)
+		return true, false
+	}
+	if windVarPattern.MatchString(token) {
+		return true, false
+	}
+
+	if m := metersPattern.FindStringSubmatch(token); m != nil {
+		meters, _ := strconv.Atoi(m[1])
+		if meters >= 9999 {
+			meters = maxVisibility
+		}
+		c.VisibilityM = &meters
+		return true, false
+	}
+	if wholeMilesPattern.MatchString(token) && statuteMiPattern.MatchString(next) && strings.Contains(next, "/") {
+		whole, _ := strconv.Atoi(token)
+		if miles, ok := parseStatuteMiles(next); ok {
+			c.VisibilityM = intPtr(milesToMeters(float64(whole) + miles))
+			return true, true
+		}
+	}
+	if statuteMiPattern.MatchString(token) {
+		if miles, ok := parseStatuteMiles(token); ok {
+			c.VisibilityM = intPtr(milesToMeters(miles))
+			return true, false
+		}
+	}
+
+	if m := cloudPattern.FindStringSubmatch(token); m != nil {
+		layer := models.CloudLayer{Cover: m[1]}
+		if m[2] != "///" {
+			base, _ := strconv.Atoi(m[2])
+			layer.BaseFt = base * 100
+		}
+		if m[3] != "///" {
+			layer.Type = m[3]
+		}
+		c.Clouds = append(c.Clouds, layer)
+		c.CeilingFt = ceiling(c.Clouds)
+		return true, false
+	}
+
+	if m := weatherPattern.FindStringSubmatch(token); m != nil && (m[2] != "" || m[3] != "") && token != "" {
+		c.Phenomena = append(c.Phenomena, token)
+		return true, false
+	}
+
+	return false, false
+}
+
+func parseWind(m []string) *models.Wind {
+	speed, _ := strconv.Atoi(m[2])
+	gust, _ := strconv.Atoi(m[3])
+
+	convert := func(v int) int {
+		switch m[4] {
+		case "MPS":
+			return int(math.Round(float64(v) * 1.94384))
+		case "KMH":
+			return int(math.Round(float64(v) / 1.852))
+		}
+		return v
+	}
+
+	wind := &models.Wind{SpeedKt: convert(speed), GustKt: convert(gust)}
+	if m[1] == "VRB" {
+		wind.Variable = true
+	} else {
+		wind.DirectionDeg, _ = strconv.Atoi(m[1])
+	}
+	return wind
+}
+
+// parseStatuteMiles parses "10SM", "1/2SM", "M1/4SM" (less than) and
+// "P6SM" (more than).
+func parseStatuteMiles(token string) (float64, bool) {
+	m := statuteMiPattern.FindStringSubmatch(token)
+	if m == nil || m[2] == "" {
+		return 0, false
+	}
+	if m[1] == "P" {
+		return math.Inf(1), true
+	}
+
+	n, _ := strconv.ParseFloat(m[2], 64)
+	if m[3] != "" {
+		d, _ := strconv.ParseFloat(m[3], 64)
+		if d == 0 {
+			return 0, false
+		}
+		n /= d
+	}
+	return n, true
+}
+
+func milesToMeters(miles float64) int {
+	return int(math.Min(maxVisibility, math.Round(miles*1609.344)))
+}
+
+// ceiling is the base of the lowest broken, overcast or obscured layer.
+func ceiling(clouds []models.CloudLayer) *int {
+	var lowest *int
+	for _, layer := range clouds {
+		switch layer.Cover {
+		case "BKN", "OVC", "VV":
+			if lowest == nil || layer.BaseFt < *lowest {
+				lowest = intPtr(layer.BaseFt)
+			}
+		}
+	}
+	return lowest
+}
+
+// merge applies a BECMG group's changes to the prevailing conditions.
+func merge(base, change models.Conditions) models.Conditions {
+	merged := base
+	if change.Wind != nil {
+		merged.Wind = change.Wind
+	}
+	if change.VisibilityM != nil {
+		merged.VisibilityM = change.VisibilityM
+	}
+	if len(change.Clouds) > 0 || change.SkyClear {
+		merged.Clouds = change.Clouds
+		merged.CeilingFt = change.CeilingFt
+		merged.SkyClear = change.SkyClear
+	}
+	if len(change.Phenomena) > 0 || change.NoWeather {
+		merged.Phenomena = change.Phenomena
+	}
+	return merged
+}
+
+// resolveTime turns a day-of-month, hour and minute, as reports give them,
+// into the nearest such time to ref: reports are at most a few days old,
+// and forecasts reach at most a few days ahead. Hour 24 is midnight at the
+// end of the day.
+func resolveTime(day, hour, minute int, ref time.Time) time.Time {
+	ref = ref.UTC()
+	best := time.Time{}
+	for _, months := range []int{-1, 0, 1} {
+		first := time.Date(ref.Year(), ref.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+		// Skip days past the end of the month (e.g. the 31st of April)
+		if day > first.AddDate(0, 1, -1).Day() {
+			continue
+		}
+		t := first.AddDate(0, 0, day-1).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+		if best.IsZero() || absDuration(t.Sub(ref)) < absDuration(best.Sub(ref)) {
+			best = t
+		}
+	}
+	return best
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+func intPtr(v int) *int {
+	return &v
+}
@@ -0,0 +1,89 @@
+package weather
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/models"
+)
+
+var (
+	stationPattern     = regexp.MustCompile(`^[A-Z][A-Z0-9]{3}$`)
+	dayTimePattern     = regexp.MustCompile(`^(\d{2})(\d{2})(\d{2})Z$`)
+	temperaturePattern = regexp.MustCompile(`^(M?\d{2})/(M?\d{2})?$`)
+)
+
+// ParseMETAR parses a METAR or SPECI, such as
+//
+//	KJFK 181751Z 28015G25KT 10SM FEW050 BKN250 18/06 A2992 RMK AO2
+//
+// ref places the report's day and time in a month, and is normally now.
+// Remarks and trend groups (BECMG, TEMPO, NOSIG) are ignored.
+func ParseMETAR(raw string, ref time.Time) (*models.METAR, error) {
+	tokens := strings.Fields(strings.TrimSuffix(strings.TrimSpace(raw), "="))
+	if len(tokens) > 0 && (tokens[0] == "METAR" || tokens[0] == "SPECI") {
+		tokens = tokens[1:]
+	}
+	if len(tokens) < 2 || !stationPattern.MatchString(tokens[0]) {
+		return nil, fmt.Errorf("invalid METAR %q: missing station", raw)
+	}
+
+	m := dayTimePattern.FindStringSubmatch(tokens[1])
+	if m == nil {
+		return nil, fmt.Errorf("invalid METAR %q: missing observation time", raw)
+	}
+	day, _ := strconv.Atoi(m[1])
+	hour, _ := strconv.Atoi(m[2])
+	minute, _ := strconv.Atoi(m[3])
+
+	metar := &models.METAR{
+		Station:    tokens[0],
+		ObservedAt: resolveTime(day, hour, minute, ref),
+		Raw:        strings.Join(strings.Fields(raw), " "),
+	}
+
+	for i := 2; i < len(tokens); i++ {
+		token := tokens[i]
+		switch token {
+		case "RMK", "BECMG", "TEMPO", "NOSIG":
+			return metar, nil
+		case "AUTO", "COR", "NIL":
+			continue
+		}
+
+		if t := temperaturePattern.FindStringSubmatch(token); t != nil {
+			metar.TemperatureC = parseTemperature(t[1])
+			metar.DewpointC = parseTemperature(t[2])
+			continue
+		}
+
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+		if _, consumed := parseGroup(&metar.Conditions, token, next); consumed {
+			i++
+		}
+	}
+
+	return metar, nil
+}
+
+// parseTemperature parses "18" or "M02" (minus two).
+func parseTemperature(s string) *int {
+	if s == "" {
+		return nil
+	}
+	negative := strings.HasPrefix(s, "M")
+	v, err := strconv.Atoi(strings.TrimPrefix(s, "M"))
+	if err != nil {
+		return nil
+	}
+	if negative {
+		v = -v
+	}
+	return &v
+}
@@ -0,0 +1,182 @@
+package weather
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/models"
+)
+
+func TestParseMETAR(t *testing.T) {
+	ref := time.Date(2026, 10, 18, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		raw        string
+		observedAt time.Time
+		wind       *models.Wind
+		visibility *int
+		ceiling    *int
+		skyClear   bool
+		phenomena  []string
+		temp, dew  *int
+	}{
+		{
+			name:       "fractional miles",
+			raw:        fixture(t, "KJFK.metar"),
+			observedAt: time.Date(2026, 10, 18, 17, 51, 0, 0, time.UTC),
+			wind:       &models.Wind{DirectionDeg: 280, SpeedKt: 15, GustKt: 25},
+			visibility: intPtr(2414), // 1 1/2 SM
+			ceiling:    intPtr(800),
+			phenomena:  []string{"-RA", "BR"},
+			temp:       intPtr(18),
+			dew:        intPtr(16),
+		},
+		{
+			name:       "less than a quarter mile under vertical visibility",
+			raw:        fixture(t, "CYYZ.metar"),
+			observedAt: time.Date(2026, 10, 18, 18, 0, 0, 0, time.UTC),
+			wind:       &models.Wind{},
+			visibility: intPtr(402),
+			ceiling:    intPtr(100),
+			phenomena:  []string{"FG"},
+			temp:       intPtr(-2),
+			dew:        intPtr(-3),
+		},
+		{
+			name:       "CAVOK",
+			raw:        fixture(t, "EGLL.metar"),
+			observedAt: time.Date(2026, 10, 18, 17, 50, 0, 0, time.UTC),
+			wind:       &models.Wind{DirectionDeg: 240, SpeedKt: 10},
+			visibility: intPtr(maxVisibility),
+			skyClear:   true,
+			temp:       intPtr(15),
+			dew:        intPtr(9),
+		},
+		{
+			name:       "trend ignored",
+			raw:        "METAR EDDF 181750Z 27008KT 9999 SCT030 12/08 Q1012 TEMPO 4000 SHRA=",
+			observedAt: time.Date(2026, 10, 18, 17, 50, 0, 0, time.UTC),
+			wind:       &models.Wind{DirectionDeg: 270, SpeedKt: 8},
+			visibility: intPtr(maxVisibility),
+			temp:       intPtr(12),
+			dew:        intPtr(8),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metar, err := ParseMETAR(tt.raw, ref)
+			if err != nil {
+				t.Fatalf("ParseMETAR: %v", err)
+			}
+
+			if !metar.ObservedAt.Equal(tt.observedAt) {
+				t.Errorf("observed at %v, want %v", metar.ObservedAt, tt.observedAt)
+			}
+			if metar.Wind == nil || *metar.Wind != *tt.wind {
+				t.Errorf("wind %+v, want %+v", metar.Wind, tt.wind)
+			}
+			checkInt(t, "visibility", metar.VisibilityM, tt.visibility)
+			checkInt(t, "ceiling", metar.CeilingFt, tt.ceiling)
+			checkInt(t, "temperature", metar.TemperatureC, tt.temp)
+			checkInt(t, "dewpoint", metar.DewpointC, tt.dew)
+			if metar.SkyClear != tt.skyClear {
+				t.Errorf("sky clear %v, want %v", metar.SkyClear, tt.skyClear)
+			}
+			if !slices.Equal(metar.Phenomena, tt.phenomena) {
+				t.Errorf("phenomena %v, want %v", metar.Phenomena, tt.phenomena)
+			}
+		})
+	}
+}
+
+func TestParseMETARInvalid(t *testing.T) {
+	for _, raw := range []string{"", "METAR", "kjfk 181751Z 28015KT", "KJFK 28015KT 10SM"} {
+		if _, err := ParseMETAR(raw, time.Now()); err == nil {
+			t.Errorf("ParseMETAR(%q) succeeded, want an error", raw)
+		}
+	}
+}
+
+func TestResolveTimeAcrossMonths(t *testing.T) {
+	tests := []struct {
+		name              string
+		day, hour, minute int
+		ref, want         time.Time
+	}{
+		{
+			name: "last month's report",
+			day:  31, hour: 23, minute: 51,
+			ref:  time.Date(2026, 11, 1, 0, 10, 0, 0, time.UTC),
+			want: time.Date(2026, 10, 31, 23, 51, 0, 0, time.UTC),
+		},
+		{
+			name: "next month's forecast",
+			day:  1, hour: 6,
+			ref:  time.Date(2026, 10, 31, 18, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 11, 1, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "next year",
+			day:  1, hour: 0, minute: 5,
+			ref:  time.Date(2026, 12, 31, 23, 50, 0, 0, time.UTC),
+			want: time.Date(2027, 1, 1, 0, 5, 0, 0, time.UTC),
+		},
+		{
+			name: "day missing from last month",
+			day:  31, hour: 12,
+			ref:  time.Date(2026, 12, 1, 6, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 12, 31, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "hour 24",
+			day:  1, hour: 24,
+			ref:  time.Date(2026, 10, 31, 18, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveTime(tt.day, tt.hour, tt.minute, tt.ref); !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fixture returns a report from testdata.
+func fixture(t *testing.T, name string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func checkInt(t *testing.T, name string, got, want *int) {
+	t.Helper()
+
+	switch {
+	case got == nil && want == nil:
+	case got == nil || want == nil:
+		t.Errorf("%s %v, want %v", name, ptrString(got), ptrString(want))
+	case *got != *want:
+		t.Errorf("%s %d, want %d", name, *got, *want)
+	}
+}
+
+func ptrString(v *int) string {
+	if v == nil {
+		return "none"
+	}
+	return strconv.Itoa(*v)
+}
@@ -0,0 +1,154 @@
+package weather
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/models"
+)
+
+// observedHorizon is how far ahead the latest observation is trusted over
+// the forecast.
+const observedHorizon = 90 * time.Minute
+
+// Risk bases, as in models.DelayRisk.Basis
+const (
+	BasisObserved = "observed"
+	BasisForecast = "forecast"
+)
+
+var riskRank = map[string]int{
+	models.DelayRiskLow:      0,
+	models.DelayRiskModerate: 1,
+	models.DelayRiskHigh:     2,
+}
+
+// Assess rates how likely conditions are to delay flights, roughly along
+// the lines of flight rules and what slows ground operations: thunderstorms,
+// freezing precipitation, fog, low ceilings, strong or gusty wind and snow.
+func Assess(c models.Conditions) (level string, reasons []string) {
+	level = models.DelayRiskLow
+	raise := func(to, reason string) {
+		if riskRank[to] > riskRank[level] {
+			level = to
+		}
+		reasons = append(reasons, reason)
+	}
+
+	for _, p := range c.Phenomena {
+		code := strings.TrimLeft(p, "+-")
+		heavy := strings.HasPrefix(p, "+")
+		vicinity := strings.HasPrefix(code, "VC")
+		switch {
+		case strings.Contains(code, "TS") && !vicinity:
+			raise(models.DelayRiskHigh, "thunderstorms")
+		case strings.Contains(code, "TS"):
+			raise(models.DelayRiskModerate, "thunderstorms nearby")
+		case strings.HasPrefix(code, "FZ") && code != "FZFG":
+			raise(models.DelayRiskHigh, "freezing precipitation")
+		case strings.Contains(code, "VA"):
+			raise(models.DelayRiskHigh, "volcanic ash")
+		case strings.Contains(code, "SS"), strings.Contains(code, "DS"), strings.Contains(code, "FC"):
+			raise(models.DelayRiskHigh, "dust storm, sandstorm or funnel cloud")
+		case strings.Contains(code, "SN") || strings.Contains(code, "PL") || strings.Contains(code, "GR"):
+			if heavy {
+				raise(models.DelayRiskHigh, "heavy snow or ice")
+			} else {
+				raise(models.DelayRiskModerate, "snow or ice")
+			}
+		case strings.Contains(code, "FG") && !vicinity:
+			raise(models.DelayRiskModerate, "fog")
+		case heavy && strings.Contains(code, "RA"):
+			raise(models.DelayRiskModerate, "heavy rain")
+		}
+	}
+
+	if c.VisibilityM != nil {
+		switch v := *c.VisibilityM; {
+		case v < 800:
+			raise(models.DelayRiskHigh, fmt.Sprintf("very low visibility (%d m)", v))
+		case v < 5000:
+			raise(models.DelayRiskModerate, fmt.Sprintf("low visibility (%d m)", v))
+		}
+	}
+
+	if c.CeilingFt != nil {
+		switch h := *c.CeilingFt; {
+		case h < 200:
+			raise(models.DelayRiskHigh, fmt.Sprintf("very low ceiling (%d ft)", h))
+		case h < 1000:
+			raise(models.DelayRiskModerate, fmt.Sprintf("low ceiling (%d ft)", h))
+		}
+	}
+
+	if w := c.Wind; w != nil {
+		peak := max(w.SpeedKt, w.GustKt)
+		switch {
+		case w.SpeedKt >= 30 || w.GustKt >= 40:
+			raise(models.DelayRiskHigh, fmt.Sprintf("strong wind (%d kt)", peak))
+		case w.SpeedKt >= 20 || w.GustKt >= 25:
+			raise(models.DelayRiskModerate, fmt.Sprintf("strong wind (%d kt)", peak))
+		}
+	}
+
+	for _, layer := range c.Clouds {
+		if layer.Type == "CB" {
+			raise(models.DelayRiskModerate, "cumulonimbus clouds")
+			break
+		}
+	}
+
+	return level, reasons
+}
+
+// RiskAt estimates the delay risk at a time from the latest observation,
+// when the time is close to it, or else the forecast. Temporary forecast
+// groups count too, a PROB30 one level lower. It returns nil when neither
+// report covers the time.
+func RiskAt(airport string, metar *models.METAR, taf *models.TAF, at, now time.Time) *models.DelayRisk {
+	risk := &models.DelayRisk{Airport: airport, At: at}
+
+	if metar != nil && at.Sub(now) <= observedHorizon && now.Sub(metar.ObservedAt) <= observedHorizon {
+		risk.Level, risk.Reasons = Assess(metar.Conditions)
+		risk.Basis = BasisObserved
+		return risk
+	}
+
+	if taf == nil {
+		return nil
+	}
+	prevailing, temporary, ok := ForecastAt(taf, at)
+	if !ok {
+		return nil
+	}
+
+	risk.Level, risk.Reasons = Assess(prevailing)
+	risk.Basis = BasisForecast
+	for _, p := range temporary {
+		level, reasons := Assess(p.Conditions)
+		if strings.HasPrefix(p.Change, "PROB30") {
+			switch level {
+			case models.DelayRiskHigh:
+				level = models.DelayRiskModerate
+			case models.DelayRiskModerate:
+				level = models.DelayRiskLow
+			}
+		}
+		if riskRank[level] > riskRank[risk.Level] {
+			risk.Level = level
+		}
+		for _, r := range reasons {
+			risk.Reasons = append(risk.Reasons, fmt.Sprintf("%s (%s)", r, strings.ToLower(p.Change)))
+		}
+	}
+	return risk
+}
+
+// Higher returns the higher of two risk levels.
+func Higher(a, b string) string {
+	if riskRank[b] > riskRank[a] {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,144 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/config"
+)
+
+// ErrNoReport is returned when a source has neither a METAR nor a TAF for
+// a station.
+var ErrNoReport = errors.New("no weather report")
+
+// Reports are the raw METAR and TAF for a station; either may be empty.
+type Reports struct {
+	METAR string `json:"metar,omitempty"`
+	TAF   string `json:"taf,omitempty"`
+}
+
+// Source fetches the latest reports for an ICAO station.
+type Source interface {
+	Name() string
+	Fetch(ctx context.Context, station string) (Reports, error)
+}
+
+// NewSource builds the configured source.
+func NewSource(cfg *config.Config) (Source, error) {
+	switch cfg.Weather.Source {
+	case "aviationweather", "":
+		return NewAviationWeatherSource(cfg.Weather.URL, &http.Client{Timeout: 10 * time.Second}), nil
+	case "file":
+		return NewFileSource(cfg.Weather.Dir), nil
+	default:
+		return nil, fmt.Errorf("unsupported weather source: %s", cfg.Weather.Source)
+	}
+}
+
+// FileSource reads <dir>/<STATION>.metar and <dir>/<STATION>.taf, for
+// development and fixtures.
+type FileSource struct {
+	Dir string
+}
+
+func NewFileSource(dir string) *FileSource {
+	return &FileSource{Dir: dir}
+}
+
+func (s *FileSource) Name() string { return "file" }
+
+func (s *FileSource) Fetch(_ context.Context, station string) (Reports, error) {
+	read := func(ext string) (string, error) {
+		data, err := os.ReadFile(filepath.Join(s.Dir, strings.ToUpper(station)+ext))
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return strings.TrimSpace(string(data)), err
+	}
+
+	var reports Reports
+	var err error
+	if reports.METAR, err = read(".metar"); err != nil {
+		return Reports{}, err
+	}
+	if reports.TAF, err = read(".taf"); err != nil {
+		return Reports{}, err
+	}
+	if reports.METAR == "" && reports.TAF == "" {
+		return Reports{}, ErrNoReport
+	}
+	return reports, nil
+}
+
+// AviationWeatherSource reads the raw text products of the
+// aviationweather.gov data API (/metar and /taf with ids and format=raw).
+type AviationWeatherSource struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewAviationWeatherSource(url string, client *http.Client) *AviationWeatherSource {
+	return &AviationWeatherSource{URL: strings.TrimSuffix(url, "/"), Client: client}
+}
+
+func (s *AviationWeatherSource) Name() string { return "aviationweather" }
+
+func (s *AviationWeatherSource) Fetch(ctx context.Context, station string) (Reports, error) {
+	var reports Reports
+	var err error
+	if reports.METAR, err = s.get(ctx, "metar", station); err != nil {
+		return Reports{}, err
+	}
+	if reports.TAF, err = s.get(ctx, "taf", station); err != nil {
+		return Reports{}, err
+	}
+	if reports.METAR == "" && reports.TAF == "" {
+		return Reports{}, ErrNoReport
+	}
+	return reports, nil
+}
+
+// get returns the newest report of a product. The API lists the newest
+// first, METARs one per line and TAFs, which span lines, separated by
+// blank lines; it answers 204 when there is none.
+func (s *AviationWeatherSource) get(ctx context.Context, product, station string) (string, error) {
+	query := url.Values{"ids": {strings.ToUpper(station)}, "format": {"raw"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL+"/"+product+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent:
+		return "", nil
+	default:
+		return "", fmt.Errorf("%s returned %s", req.URL.Redacted(), resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return "", err
+	}
+	text := strings.TrimSpace(strings.ReplaceAll(string(body), "\r\n", "\n"))
+	separator := "\n\n"
+	if product == "metar" {
+		separator = "\n"
+	}
+	first, _, _ := strings.Cut(text, separator)
+	return strings.TrimSpace(first), nil
+}
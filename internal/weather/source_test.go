@@ -0,0 +1,34 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFileSource(t *testing.T) {
+	source := NewFileSource("testdata")
+	ctx := context.Background()
+
+	reports, err := source.Fetch(ctx, "kjfk")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if !strings.HasPrefix(reports.METAR, "KJFK 181751Z") || !strings.HasPrefix(reports.TAF, "TAF KJFK 181730Z") {
+		t.Errorf("got %+v", reports)
+	}
+
+	// A station with only a METAR
+	reports, err = source.Fetch(ctx, "EGLL")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if reports.METAR == "" || reports.TAF != "" {
+		t.Errorf("got %+v, want only a METAR", reports)
+	}
+
+	if _, err := source.Fetch(ctx, "ZZZZ"); !errors.Is(err, ErrNoReport) {
+		t.Errorf("Fetch of an unknown station: %v, want ErrNoReport", err)
+	}
+}
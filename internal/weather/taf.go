@@ -0,0 +1,184 @@
+package weather
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/models"
+)
+
+// TAF change groups, as in models.TAFPeriod.Change
+const (
+	ChangeBase  = "BASE"
+	ChangeFrom  = "FM"
+	ChangeBecmg = "BECMG"
+	ChangeTempo = "TEMPO"
+)
+
+var (
+	periodPattern = regexp.MustCompile(`^(\d{2})(\d{2})/(\d{2})(\d{2})$`)
+	fromPattern   = regexp.MustCompile(`^FM(\d{2})(\d{2})(\d{2})$`)
+	probPattern   = regexp.MustCompile(`^PROB(30|40)$`)
+	issuedPattern = regexp.MustCompile(`^(\d{2})(\d{2})(\d{2})Z$`)
+)
+
+// ParseTAF parses a TAF, such as
+//
+//	TAF KJFK 181730Z 1818/1924 28012KT P6SM FEW250
+//	  FM190200 30008KT P6SM SCT040
+//	  TEMPO 1906/1910 3SM -SHRA BKN015
+//	  PROB30 1914/1918 TSRA BKN030CB
+//
+// ref places the report's days and times in a month, and is normally now.
+func ParseTAF(raw string, ref time.Time) (*models.TAF, error) {
+	tokens := strings.Fields(strings.TrimSuffix(strings.TrimSpace(raw), "="))
+	for len(tokens) > 0 && (tokens[0] == "TAF" || tokens[0] == "AMD" || tokens[0] == "COR") {
+		tokens = tokens[1:]
+	}
+	if len(tokens) < 2 || !stationPattern.MatchString(tokens[0]) {
+		return nil, fmt.Errorf("invalid TAF %q: missing station", raw)
+	}
+
+	taf := &models.TAF{
+		Station: tokens[0],
+		Raw:     strings.Join(strings.Fields(raw), " "),
+	}
+	i := 1
+
+	if m := issuedPattern.FindStringSubmatch(tokens[i]); m != nil {
+		day, _ := strconv.Atoi(m[1])
+		hour, _ := strconv.Atoi(m[2])
+		minute, _ := strconv.Atoi(m[3])
+		taf.IssuedAt = resolveTime(day, hour, minute, ref)
+		ref = taf.IssuedAt
+		i++
+	}
+
+	if i >= len(tokens) {
+		return nil, fmt.Errorf("invalid TAF %q: missing validity", raw)
+	}
+	from, to, ok := parsePeriod(tokens[i], ref)
+	if !ok {
+		return nil, fmt.Errorf("invalid TAF %q: missing validity", raw)
+	}
+	taf.ValidFrom, taf.ValidTo = from, to
+	i++
+
+	current := models.TAFPeriod{Change: ChangeBase, From: from, To: to}
+	for ; i < len(tokens); i++ {
+		token := tokens[i]
+
+		var next models.TAFPeriod
+		var started bool
+		switch {
+		case token == "RMK":
+			i = len(tokens)
+			continue
+		case fromPattern.MatchString(token):
+			m := fromPattern.FindStringSubmatch(token)
+			day, _ := strconv.Atoi(m[1])
+			hour, _ := strconv.Atoi(m[2])
+			minute, _ := strconv.Atoi(m[3])
+			next = models.TAFPeriod{Change: ChangeFrom, From: resolveTime(day, hour, minute, taf.ValidFrom), To: taf.ValidTo}
+			started = true
+		case token == ChangeBecmg || token == ChangeTempo || probPattern.MatchString(token):
+			change := token
+			if probPattern.MatchString(token) && i+1 < len(tokens) && tokens[i+1] == ChangeTempo {
+				change += " " + ChangeTempo
+				i++
+			}
+			if i+1 >= len(tokens) {
+				continue
+			}
+			pFrom, pTo, ok := parsePeriod(tokens[i+1], taf.ValidFrom)
+			if !ok {
+				continue
+			}
+			i++
+			next = models.TAFPeriod{Change: change, From: pFrom, To: pTo}
+			started = true
+		}
+
+		if started {
+			taf.Periods = append(taf.Periods, current)
+			current = next
+			continue
+		}
+
+		lookahead := ""
+		if i+1 < len(tokens) {
+			lookahead = tokens[i+1]
+		}
+		if _, consumed := parseGroup(&current.Conditions, token, lookahead); consumed {
+			i++
+		}
+	}
+	taf.Periods = append(taf.Periods, current)
+
+	// An FM group lasts until the next one
+	var lastFrom *models.TAFPeriod
+	for j := range taf.Periods {
+		p := &taf.Periods[j]
+		if p.Change != ChangeFrom {
+			continue
+		}
+		if lastFrom != nil {
+			lastFrom.To = p.From
+		}
+		lastFrom = p
+	}
+
+	return taf, nil
+}
+
+// ForecastAt returns the prevailing forecast conditions at a time, from
+// the base conditions, FM groups and BECMG changes, plus the TEMPO and
+// PROB groups covering it. ok is false outside the TAF's validity.
+func ForecastAt(taf *models.TAF, at time.Time) (prevailing models.Conditions, temporary []models.TAFPeriod, ok bool) {
+	if at.Before(taf.ValidFrom) || !at.Before(taf.ValidTo) {
+		return models.Conditions{}, nil, false
+	}
+
+	for _, p := range taf.Periods {
+		switch p.Change {
+		case ChangeBase:
+			prevailing = p.Conditions
+		case ChangeFrom:
+			if !at.Before(p.From) {
+				prevailing = p.Conditions
+			}
+		case ChangeBecmg:
+			// Conditions may change any time in the period; assume the start.
+			if !at.Before(p.From) {
+				prevailing = merge(prevailing, p.Conditions)
+			}
+		default:
+			if !at.Before(p.From) && at.Before(p.To) {
+				temporary = append(temporary, p)
+			}
+		}
+	}
+	return prevailing, temporary, true
+}
+
+// parsePeriod parses "DDHH/DDHH", resolving the days near ref.
+func parsePeriod(token string, ref time.Time) (from, to time.Time, ok bool) {
+	m := periodPattern.FindStringSubmatch(token)
+	if m == nil {
+		return time.Time{}, time.Time{}, false
+	}
+	fromDay, _ := strconv.Atoi(m[1])
+	fromHour, _ := strconv.Atoi(m[2])
+	toDay, _ := strconv.Atoi(m[3])
+	toHour, _ := strconv.Atoi(m[4])
+
+	from = resolveTime(fromDay, fromHour, 0, ref)
+	to = resolveTime(toDay, toHour, 0, from)
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
@@ -0,0 +1,105 @@
+package weather
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseTAF(t *testing.T) {
+	ref := time.Date(2026, 10, 18, 18, 0, 0, 0, time.UTC)
+	day := func(d, hour int) time.Time { return time.Date(2026, 10, d, hour, 0, 0, 0, time.UTC) }
+
+	taf, err := ParseTAF(fixture(t, "KJFK.taf"), ref)
+	if err != nil {
+		t.Fatalf("ParseTAF: %v", err)
+	}
+
+	if taf.Station != "KJFK" || !taf.IssuedAt.Equal(time.Date(2026, 10, 18, 17, 30, 0, 0, time.UTC)) {
+		t.Errorf("got %s issued %v", taf.Station, taf.IssuedAt)
+	}
+	if !taf.ValidFrom.Equal(day(18, 18)) || !taf.ValidTo.Equal(day(20, 0)) {
+		t.Errorf("valid %v to %v", taf.ValidFrom, taf.ValidTo)
+	}
+
+	var changes []string
+	for _, p := range taf.Periods {
+		changes = append(changes, p.Change)
+	}
+	if want := []string{ChangeBase, ChangeBecmg, ChangeTempo, ChangeFrom, "PROB30 TEMPO"}; !slices.Equal(changes, want) {
+		t.Fatalf("periods %v, want %v", changes, want)
+	}
+	if fm := taf.Periods[3]; !fm.From.Equal(day(19, 12)) || !fm.To.Equal(taf.ValidTo) {
+		t.Errorf("FM group from %v to %v", fm.From, fm.To)
+	}
+
+	tests := []struct {
+		name       string
+		at         time.Time
+		windDeg    int
+		visibility *int
+		ceiling    *int
+		temporary  []string
+	}{
+		{name: "base", at: day(18, 20), windDeg: 280, visibility: intPtr(maxVisibility)},
+		{name: "after BECMG", at: day(19, 3), windDeg: 310, visibility: intPtr(4828), ceiling: intPtr(1000)},
+		{name: "in TEMPO", at: day(19, 7), windDeg: 310, visibility: intPtr(4828), ceiling: intPtr(1000), temporary: []string{ChangeTempo}},
+		{name: "after FM", at: day(19, 15), windDeg: 300, visibility: intPtr(maxVisibility), temporary: []string{"PROB30 TEMPO"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prevailing, temporary, ok := ForecastAt(taf, tt.at)
+			if !ok {
+				t.Fatal("not covered by the TAF")
+			}
+			if prevailing.Wind == nil || prevailing.Wind.DirectionDeg != tt.windDeg {
+				t.Errorf("wind %+v, want from %d", prevailing.Wind, tt.windDeg)
+			}
+			checkInt(t, "visibility", prevailing.VisibilityM, tt.visibility)
+			checkInt(t, "ceiling", prevailing.CeilingFt, tt.ceiling)
+
+			var changes []string
+			for _, p := range temporary {
+				changes = append(changes, p.Change)
+			}
+			if !slices.Equal(changes, tt.temporary) {
+				t.Errorf("temporary %v, want %v", changes, tt.temporary)
+			}
+		})
+	}
+
+	tempo := taf.Periods[2]
+	checkInt(t, "TEMPO visibility", tempo.VisibilityM, intPtr(805))
+	checkInt(t, "TEMPO ceiling", tempo.CeilingFt, intPtr(200))
+
+	if _, _, ok := ForecastAt(taf, taf.ValidTo); ok {
+		t.Error("ForecastAt covers the end of validity")
+	}
+}
+
+func TestParseTAFAcrossMonthEnd(t *testing.T) {
+	raw := "TAF AMD EGLL 311700Z 3118/0124 24012KT 9999 SCT030 BECMG 0103/0105 BKN012"
+
+	taf, err := ParseTAF(raw, time.Date(2026, 10, 31, 17, 5, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("ParseTAF: %v", err)
+	}
+	if want := time.Date(2026, 10, 31, 18, 0, 0, 0, time.UTC); !taf.ValidFrom.Equal(want) {
+		t.Errorf("valid from %v, want %v", taf.ValidFrom, want)
+	}
+	if want := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC); !taf.ValidTo.Equal(want) {
+		t.Errorf("valid to %v, want %v", taf.ValidTo, want)
+	}
+	if becmg := taf.Periods[1]; !becmg.From.Equal(time.Date(2026, 11, 1, 3, 0, 0, 0, time.UTC)) {
+		t.Errorf("BECMG from %v", becmg.From)
+	}
+}
+
+func TestParseTAFInvalid(t *testing.T) {
+	for _, raw := range []string{"TAF", "TAF KJFK 181730Z", "TAF KJFK 181730Z 1818/1818 28012KT"} {
+		if _, err := ParseTAF(raw, time.Now()); err == nil {
+			t.Errorf("ParseTAF(%q) succeeded, want an error", raw)
+		}
+	}
+}
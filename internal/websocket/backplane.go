@@ -0,0 +1,159 @@
+package websocket
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/database"
+	"github.com/onoja123/travel-companion-backend/internal/models"
+)
+
+// backplaneChannel is the Redis pub/sub channel shared by all replicas.
+const backplaneChannel = "ws:events"
+
+// envelope is an event as it travels between replicas: one delivery per
+// follower, each carrying that user's sequence number, plus an unsequenced
+// copy for topic subscribers who are not followers.
+type envelope struct {
+	Origin     string     `json:"origin"`
+	Deliveries []delivery `json:"deliveries"`
+}
+
+type delivery struct {
+	Audience models.Audience `json:"audience"`
+	Except   []string        `json:"except,omitempty"`
+	Seq      int64           `json:"seq,omitempty"`
+	Event    json.RawMessage `json:"event"`
+}
+
+// Backplane fans events out across server replicas over Redis pub/sub. An
+// event published on one replica is delivered to local connections
+// immediately and to connections on every other replica via Redis, so a
+// user reaches the event no matter which instance holds their socket.
+type Backplane struct {
+	hub    *Hub
+	redis  *database.RedisClient
+	log    *EventLog
+	nodeID string
+}
+
+func NewBackplane(hub *Hub, redisClient *database.RedisClient) *Backplane {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+
+	return &Backplane{
+		hub:    hub,
+		redis:  redisClient,
+		log:    NewEventLog(redisClient),
+		nodeID: hex.EncodeToString(buf),
+	}
+}
+
+// Publish records the event in each addressed user's event log, delivers it
+// locally and forwards it to the other replicas.
+func (b *Backplane) Publish(audience models.Audience, event models.Event) {
+	var deliveries []delivery
+	if len(audience.UserIDs) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		seqs, messages, err := b.log.Append(ctx, audience.UserIDs, event)
+		cancel()
+		if err != nil {
+			// Still deliver live to the users not recorded; the event just
+			// cannot be replayed for them.
+			log.Printf("Failed to record %s event for %d users: %v", event.Type, len(audience.UserIDs)-len(seqs), err)
+			message, err := json.Marshal(event)
+			if err != nil {
+				log.Printf("Failed to marshal %s event: %v", event.Type, err)
+				return
+			}
+			for len(messages) < len(audience.UserIDs) {
+				seqs = append(seqs, 0)
+				messages = append(messages, message)
+			}
+		}
+
+		for i, userID := range audience.UserIDs {
+			deliveries = append(deliveries, delivery{
+				Audience: models.Audience{UserIDs: []string{userID}},
+				Seq:      seqs[i],
+				Event:    messages[i],
+			})
+		}
+	}
+
+	if len(audience.Topics) > 0 {
+		message, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("Failed to marshal %s event: %v", event.Type, err)
+			return
+		}
+
+		deliveries = append(deliveries, delivery{
+			Audience: models.Audience{Topics: audience.Topics},
+			Except:   audience.UserIDs,
+			Event:    message,
+		})
+	}
+
+	if len(deliveries) == 0 {
+		return
+	}
+
+	b.deliver(deliveries)
+
+	data, err := json.Marshal(envelope{Origin: b.nodeID, Deliveries: deliveries})
+	if err != nil {
+		log.Printf("Failed to marshal %s envelope: %v", event.Type, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := b.redis.Client.Publish(ctx, backplaneChannel, data).Err(); err != nil {
+		log.Printf("Failed to publish %s event to backplane: %v", event.Type, err)
+	}
+}
+
+func (b *Backplane) deliver(deliveries []delivery) {
+	for _, d := range deliveries {
+		b.hub.deliver(d.Audience, d.Except, frame{seq: d.Seq, data: d.Event})
+	}
+}
+
+// Run relays events from other replicas to local connections until ctx is
+// cancelled. The Redis client resubscribes automatically after a dropped
+// connection; events published while disconnected are not replayed.
+func (b *Backplane) Run(ctx context.Context) {
+	sub := b.redis.Client.Subscribe(ctx, backplaneChannel)
+	defer sub.Close()
+
+	log.Printf("📡 WebSocket backplane subscribed as node %s", b.nodeID)
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+
+			var env envelope
+			if err := json.Unmarshal([]byte(msg.Payload), &env); err != nil {
+				log.Printf("Invalid backplane message: %v", err)
+				continue
+			}
+			if env.Origin == b.nodeID {
+				continue // already delivered locally
+			}
+
+			b.deliver(env.Deliveries)
+		}
+	}
+}
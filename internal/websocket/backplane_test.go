@@ -0,0 +1,158 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/onoja123/travel-companion-backend/internal/config"
+	"github.com/onoja123/travel-companion-backend/internal/database"
+	"github.com/onoja123/travel-companion-backend/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestBackplaneFanOutAcrossHubs(t *testing.T) {
+	mr := miniredis.RunT(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Two replicas sharing one Redis
+	hubA, backplaneA := newTestReplica(t, mr)
+	hubB, backplaneB := newTestReplica(t, mr)
+	go backplaneA.Run(ctx)
+	go backplaneB.Run(ctx)
+	waitForSubscribers(t, mr, 2)
+
+	topic := models.FlightTopic("AA100_2026-10-19")
+
+	followerA := hubA.newClient("alice", "websocket")
+	followerB := hubB.newClient("alice", "websocket")
+	watcherB := hubB.newClient("bob", "websocket")
+	for _, c := range []*Client{followerA, followerB, watcherB} {
+		if !c.hub.Register(c) {
+			t.Fatal("Register failed")
+		}
+	}
+	if err := hubB.Subscribe(watcherB, topic); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	// A follower also subscribed to the topic still gets one copy
+	if err := hubB.Subscribe(followerB, topic); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	audience := models.Audience{UserIDs: []string{"alice"}, Topics: []string{topic}}
+	backplaneA.Publish(audience, models.Event{Type: models.EventGateChanged, FlightKey: "AA100_2026-10-19", Timestamp: time.Now()})
+
+	for name, c := range map[string]*Client{"local follower": followerA, "remote follower": followerB} {
+		f := receive(t, c)
+		if f.seq != 1 {
+			t.Errorf("%s got seq %d, want 1", name, f.seq)
+		}
+		if event := decodeEvent(t, f); event.Seq != 1 || event.Type != models.EventGateChanged {
+			t.Errorf("%s got %+v", name, event)
+		}
+	}
+	if f := receive(t, watcherB); f.seq != 0 {
+		t.Errorf("topic subscriber got seq %d, want an unsequenced event", f.seq)
+	}
+
+	// The next event for alice continues her sequence whichever replica
+	// publishes it
+	backplaneB.Publish(models.Audience{UserIDs: []string{"alice"}}, models.Event{Type: models.EventDelayChanged, Timestamp: time.Now()})
+	for name, c := range map[string]*Client{"remote follower": followerA, "local follower": followerB} {
+		if f := receive(t, c); f.seq != 2 {
+			t.Errorf("%s got seq %d, want 2", name, f.seq)
+		}
+	}
+
+	// Nobody gets a second copy: the origin skips its own relayed envelope
+	time.Sleep(50 * time.Millisecond)
+	for name, c := range map[string]*Client{"alice on A": followerA, "alice on B": followerB, "bob on B": watcherB} {
+		if n := len(c.send); n != 0 {
+			t.Errorf("%s has %d extra queued event(s)", name, n)
+		}
+	}
+}
+
+func newTestReplica(t *testing.T, mr *miniredis.Miniredis) (*Hub, *Backplane) {
+	t.Helper()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	hub := NewHub(&config.Config{WS: config.WebSocketConfig{SendQueueSize: 16, DropPolicy: string(DropOldest)}})
+	return hub, NewBackplane(hub, &database.RedisClient{Client: client})
+}
+
+func waitForSubscribers(t *testing.T, mr *miniredis.Miniredis, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for mr.PubSubNumSub(backplaneChannel)[backplaneChannel] < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("backplanes did not subscribe")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func receive(t *testing.T, c *Client) frame {
+	t.Helper()
+
+	select {
+	case f := <-c.send:
+		return f
+	case <-time.After(2 * time.Second):
+		t.Fatalf("%s: no event received", c.UserID)
+		return frame{}
+	}
+}
+
+func decodeEvent(t *testing.T, f frame) models.Event {
+	t.Helper()
+
+	var event models.Event
+	if err := json.Unmarshal(f.data, &event); err != nil {
+		t.Fatalf("invalid event %s: %v", f.data, err)
+	}
+	return event
+}
+
+func TestPublishToManyFollowers(t *testing.T) {
+	mr := miniredis.RunT(t)
+	hub, backplane := newTestReplica(t, mr)
+
+	// More followers than one append batch; the first already has events
+	var followers []*Client
+	var userIDs []string
+	for i := range appendBatchSize + 50 {
+		userID := fmt.Sprintf("user-%d", i)
+		client := hub.newClient(userID, "websocket")
+		hub.Register(client)
+		followers = append(followers, client)
+		userIDs = append(userIDs, userID)
+	}
+	mr.Set(seqKey("user-0"), "41")
+
+	backplane.Publish(models.Audience{UserIDs: userIDs}, models.Event{Type: models.EventGateChanged, Timestamp: time.Now()})
+
+	for i, c := range followers {
+		want := int64(1)
+		if i == 0 {
+			want = 42
+		}
+		f := receive(t, c)
+		if event := decodeEvent(t, f); f.seq != want || event.Seq != want {
+			t.Fatalf("%s got seq %d (event %d), want %d", c.UserID, f.seq, event.Seq, want)
+		}
+	}
+
+	events, gap, err := backplane.log.Since(context.Background(), "user-249", 0)
+	if err != nil || gap || len(events) != 1 {
+		t.Errorf("last follower's log: %d events, gap %v, err %v", len(events), gap, err)
+	}
+}
@@ -8,25 +8,44 @@ import (
 )
 
 const (
-	writeWait      = 10 * time.Second
-	pongWait       = 60 * time.Second
-	pingPeriod     = (pongWait * 9) / 10
-	maxMessageSize = 512
+	writeWait       = 10 * time.Second
+	pongWait        = 60 * time.Second
+	pingPeriod      = (pongWait * 9) / 10
+	heartbeatPeriod = 30 * time.Second
+	maxMessageSize  = 512
 )
 
+// frame is a queued outbound message. Sequenced events carry their per-user
+// sequence number so events replayed on resume are not sent twice.
+type frame struct {
+	seq  int64
+	data []byte
+}
+
 type Client struct {
-	hub    *Hub
-	conn   *websocket.Conn
-	send   chan []byte
-	UserID string
+	hub        *Hub
+	conn       *websocket.Conn
+	send       chan frame
+	replayed   int64 // highest sequence number the client has from before or during replay
+	authorizer TopicAuthorizer
+	topics     map[string]bool // guarded by hub.mu
+	transport  string          // "websocket" or "sse"
+	stats      clientStats
+	UserID     string
+
+	// Location state, owned by readPump
+	locations    LocationTracker
+	lastLocation time.Time
+	urgency      map[string]string // tracked flight ID -> last reported urgency
 }
 
 func (c *Client) readPump() {
 	defer func() {
-		c.hub.unregister <- c
+		c.hub.Unregister(c)
 		c.conn.Close()
 	}()
 
+	c.conn.SetReadLimit(maxMessageSize)
 	c.conn.SetReadDeadline(time.Now().Add(pongWait))
 	c.conn.SetPongHandler(func(string) error {
 		c.conn.SetReadDeadline(time.Now().Add(pongWait))
@@ -42,41 +61,64 @@ func (c *Client) readPump() {
 			break
 		}
 
-		log.Printf("Received message from %s: %s", c.UserID, message)
+		c.handleMessage(message)
 	}
 }
 
 func (c *Client) writePump() {
 	ticker := time.NewTicker(pingPeriod)
+	heartbeat := time.NewTicker(heartbeatPeriod)
 	defer func() {
 		ticker.Stop()
+		heartbeat.Stop()
 		c.conn.Close()
 	}()
 
 	for {
 		select {
-		case message, ok := <-c.send:
+		case f, ok := <-c.send:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
 				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
 				return
 			}
 
+			if c.alreadyReplayed(f) {
+				continue
+			}
+
 			w, err := c.conn.NextWriter(websocket.TextMessage)
 			if err != nil {
 				return
 			}
-			w.Write(message)
+			w.Write(f.data)
 
 			if err := w.Close(); err != nil {
 				return
 			}
+			c.stats.sent.Add(1)
 
 		case <-ticker.C:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
+
+		case <-heartbeat.C:
+			// Application-level heartbeat for clients (e.g. browsers) that
+			// cannot observe protocol pings.
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteJSON(serverMessage{Type: msgHeartbeat, Timestamp: time.Now()}); err != nil {
+				return
+			}
 		}
 	}
 }
+
+// alreadyReplayed reports whether f was queued live while the same event was
+// being replayed. Only the replayed range is skipped: live events may arrive
+// out of order, as publishers on different goroutines and replicas race
+// between taking a sequence number and delivering.
+func (c *Client) alreadyReplayed(f frame) bool {
+	return f.seq > 0 && f.seq <= c.replayed
+}
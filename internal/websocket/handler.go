@@ -1,43 +1,212 @@
 package websocket
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/onoja123/travel-companion-backend/internal/config"
+	"github.com/onoja123/travel-companion-backend/internal/database"
+	"github.com/onoja123/travel-companion-backend/internal/utils"
 )
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins for now
-	},
+// bearerProtocol is the subprotocol marker for passing a JWT in
+// Sec-WebSocket-Protocol: the client offers ["bearer", "<token>"] and the
+// server selects "bearer".
+const bearerProtocol = "bearer"
+
+type Handler struct {
+	hub        *Hub
+	jwtSecret  string
+	tickets    *TicketStore
+	events     *EventLog
+	authorizer TopicAuthorizer
+	locations  LocationTracker
+	upgrader   websocket.Upgrader
 }
 
-func ServeWS(hub *Hub, c *gin.Context) {
-	userID := c.Query("user_id")
-	if userID == "" {
-		c.JSON(400, gin.H{"error": "user_id required"})
+func NewHandler(hub *Hub, cfg *config.Config, redisClient *database.RedisClient, authorizer TopicAuthorizer, locations LocationTracker) *Handler {
+	return &Handler{
+		hub:        hub,
+		jwtSecret:  cfg.JWT.Secret,
+		authorizer: authorizer,
+		locations:  locations,
+		tickets:    NewTicketStore(redisClient, cfg.WS.TicketTTL),
+		events:     NewEventLog(redisClient),
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     originChecker(cfg.WS.AllowedOrigins),
+		},
+	}
+}
+
+// ServeWS godoc
+// @Summary Open a realtime connection
+// @Description Upgrade to a WebSocket. Clients then send JSON subscribe/unsubscribe/ping messages and location fixes (see protocol.go). Authenticate with a ticket from POST /api/ws/ticket (?ticket=...), an Authorization: Bearer header, or the subprotocols ["bearer", "<jwt>"]. Pass the seq of the last event received as last_seq to replay missed events before live ones.
+// @Tags realtime
+// @Param ticket query string false "Single-use connection ticket"
+// @Param last_seq query int false "Resume after this event sequence number"
+// @Router /ws [get]
+func (h *Handler) ServeWS(c *gin.Context) {
+	userID, protocol, err := h.authenticate(c)
+	if err != nil {
+		utils.ErrorResponse(c, 401, err.Error())
 		return
 	}
 
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	resume := false
+	var lastSeq int64
+	if raw, ok := c.GetQuery("last_seq"); ok {
+		lastSeq, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || lastSeq < 0 {
+			utils.ErrorResponse(c, 400, "Invalid last_seq")
+			return
+		}
+		resume = true
+	}
+
+	var responseHeader http.Header
+	if protocol != "" {
+		responseHeader = http.Header{"Sec-WebSocket-Protocol": {protocol}}
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, responseHeader)
 	if err != nil {
 		log.Printf("WebSocket upgrade error: %v", err)
 		return
 	}
 
-	client := &Client{
-		hub:    hub,
-		conn:   conn,
-		send:   make(chan []byte, 256),
-		UserID: userID,
+	client := h.hub.newClient(userID, "websocket")
+	client.conn = conn
+	client.authorizer = h.authorizer
+	client.locations = h.locations
+	client.urgency = make(map[string]string)
+
+	// Register before reading the log so nothing published in between is
+	// missed; the writer drops live events the replay already covered.
+	if !h.hub.Register(client) {
+		conn.Close()
+		return
 	}
 
-	client.hub.register <- client
+	if resume {
+		if err := h.replay(client, lastSeq); err != nil {
+			log.Printf("WebSocket replay for %s failed: %v", userID, err)
+			h.hub.Unregister(client)
+			conn.Close()
+			return
+		}
+	}
 
 	go client.writePump()
 	go client.readPump()
 }
+
+// IssueTicket godoc
+// @Summary Issue a WebSocket connection ticket
+// @Description Exchange the caller's JWT for a short-lived, single-use ticket to pass as /ws?ticket=...
+// @Tags realtime
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Router /api/ws/ticket [post]
+func (h *Handler) IssueTicket(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorResponse(c, 401, "Unauthorized")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ticket, err := h.tickets.Issue(ctx, userID)
+	if err != nil {
+		utils.ErrorResponse(c, 500, "Failed to issue ticket")
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Ticket issued", gin.H{
+		"ticket":     ticket,
+		"expires_in": int(h.tickets.ttl.Seconds()),
+	})
+}
+
+// authenticate resolves the connecting user from a ticket, an Authorization
+// header or the bearer subprotocol, in that order. It returns the
+// subprotocol to echo back, if any.
+func (h *Handler) authenticate(c *gin.Context) (userID, protocol string, err error) {
+	if ticket := c.Query("ticket"); ticket != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		userID, err := h.tickets.Redeem(ctx, ticket)
+		return userID, "", err
+	}
+
+	if header := c.GetHeader("Authorization"); header != "" {
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found {
+			return "", "", errors.New("invalid authorization format")
+		}
+		userID, err := h.validate(token)
+		return userID, "", err
+	}
+
+	protocols := websocket.Subprotocols(c.Request)
+	for i, p := range protocols {
+		if p == bearerProtocol && i+1 < len(protocols) {
+			userID, err := h.validate(protocols[i+1])
+			return userID, bearerProtocol, err
+		}
+	}
+
+	return "", "", errors.New("authentication required")
+}
+
+func (h *Handler) validate(token string) (string, error) {
+	claims, err := utils.ValidateToken(token, h.jwtSecret)
+	if err != nil || claims.UserID == "" {
+		return "", errors.New("invalid or expired token")
+	}
+	return claims.UserID, nil
+}
+
+// originChecker allows requests without an Origin header (native apps;
+// browsers always send one on upgrades), same-origin requests and browser
+// origins on the allow-list. Any other cross-origin upgrade is rejected,
+// including when no origins are configured. Wildcards are not supported.
+func originChecker(allowed []string) func(r *http.Request) bool {
+	set := make(map[string]bool, len(allowed))
+	for _, origin := range allowed {
+		if strings.Contains(origin, "*") {
+			log.Printf("Warning: ignoring WebSocket origin %q, list origins explicitly", origin)
+			continue
+		}
+		set[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+		if set[strings.ToLower(origin)] {
+			return true
+		}
+
+		log.Printf("WebSocket origin rejected: %s", origin)
+		return false
+	}
+}
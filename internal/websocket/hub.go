@@ -1,72 +1,305 @@
 package websocket
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"log"
+	"slices"
 	"sync"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/config"
+	"github.com/onoja123/travel-companion-backend/internal/models"
+)
+
+// maxSubscriptions caps the topics a single connection may subscribe to.
+const maxSubscriptions = 100
+
+// DropPolicy decides what happens when a connection's send queue is full.
+type DropPolicy string
+
+const (
+	DropOldest DropPolicy = "drop_oldest" // discard the oldest queued message
+	DropNewest DropPolicy = "drop_newest" // discard the message being sent
+	Disconnect DropPolicy = "disconnect"  // close the connection; clients resume with last_seq
 )
 
+// Hub tracks live connections and routes events to them.
+//
+// mu guards clients, users, topics and every Client.topics. Registration,
+// subscription changes and removal take the write lock. Delivery holds only
+// the read lock and never mutates the indexes; a client evicted by the
+// disconnect policy is removed asynchronously under the write lock. A
+// client's send channel is closed only by removeLocked, which runs once per
+// client because it first checks membership.
 type Hub struct {
-	clients    map[*Client]bool
-	broadcast  chan []byte
-	register   chan *Client
-	unregister chan *Client
-	mu         sync.RWMutex
+	clients   map[*Client]bool
+	users     map[string]map[*Client]bool // user ID -> connections
+	topics    map[string]map[*Client]bool // topic -> subscribed connections
+	queueSize int
+	policy    DropPolicy
+	stats     hubStats
+	done      chan struct{}
+	mu        sync.RWMutex
 }
 
-func NewHub() *Hub {
+func NewHub(cfg *config.Config) *Hub {
+	queueSize := cfg.WS.SendQueueSize
+	if queueSize <= 0 {
+		queueSize = 256
+	}
+
+	policy := DropPolicy(cfg.WS.DropPolicy)
+	switch policy {
+	case DropOldest, DropNewest, Disconnect:
+	default:
+		log.Printf("Unknown WebSocket drop policy %q, using %s", cfg.WS.DropPolicy, DropOldest)
+		policy = DropOldest
+	}
+
 	return &Hub{
-		clients:    make(map[*Client]bool),
-		broadcast:  make(chan []byte),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
+		clients:   make(map[*Client]bool),
+		users:     make(map[string]map[*Client]bool),
+		topics:    make(map[string]map[*Client]bool),
+		queueSize: queueSize,
+		policy:    policy,
+		done:      make(chan struct{}),
 	}
 }
 
-func (h *Hub) Run() {
-	for {
-		select {
-		case client := <-h.register:
-			h.mu.Lock()
-			h.clients[client] = true
-			h.mu.Unlock()
-			log.Printf("Client connected: %s", client.UserID)
-
-		case client := <-h.unregister:
-			h.mu.Lock()
-			if _, ok := h.clients[client]; ok {
-				delete(h.clients, client)
-				close(client.send)
-				log.Printf("Client disconnected: %s", client.UserID)
-			}
-			h.mu.Unlock()
-
-		case message := <-h.broadcast:
-			h.mu.RLock()
-			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					close(client.send)
-					delete(h.clients, client)
-				}
+// Run blocks until ctx is cancelled, then disconnects every client.
+func (h *Hub) Run(ctx context.Context) {
+	<-ctx.Done()
+	h.shutdown()
+}
+
+// Register adds a client. It returns false once the hub has stopped. The
+// client is indexed when Register returns, so it can subscribe immediately.
+func (h *Hub) Register(client *Client) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	select {
+	case <-h.done:
+		return false
+	default:
+	}
+
+	h.clients[client] = true
+	addToIndex(h.users, client.UserID, client)
+	h.stats.connected.Add(1)
+	log.Printf("Client connected: %s", client.UserID)
+	return true
+}
+
+// Unregister removes a client; it is a no-op if the client is already gone.
+func (h *Hub) Unregister(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.removeLocked(client) {
+		log.Printf("Client disconnected: %s", client.UserID)
+	}
+}
+
+// Subscribe adds client to topic's index.
+func (h *Hub) Subscribe(client *Client, topic string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.clients[client] {
+		return fmt.Errorf("connection closed")
+	}
+	if client.topics[topic] {
+		return nil
+	}
+	if len(client.topics) >= maxSubscriptions {
+		return fmt.Errorf("subscription limit of %d reached", maxSubscriptions)
+	}
+
+	client.topics[topic] = true
+	addToIndex(h.topics, topic, client)
+	return nil
+}
+
+// Unsubscribe removes client from topic's index.
+func (h *Hub) Unsubscribe(client *Client, topic string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(client.topics, topic)
+	removeFromIndex(h.topics, topic, client)
+}
+
+// Publish delivers an event to its audience using the user and topic indexes.
+func (h *Hub) Publish(audience models.Audience, event models.Event) {
+	message, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to marshal %s event: %v", event.Type, err)
+		return
+	}
+
+	h.deliver(audience, nil, frame{data: message})
+}
+
+// PublishToUser sends an event to every live connection of a user.
+func (h *Hub) PublishToUser(userID string, event models.Event) {
+	h.Publish(models.Audience{UserIDs: []string{userID}}, event)
+}
+
+// Broadcast sends a message to every connection.
+func (h *Hub) Broadcast(message []byte) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.clients {
+		h.deliverLocked(client, frame{data: message})
+	}
+}
+
+func (h *Hub) BroadcastToUser(userID string, message []byte) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.users[userID] {
+		h.deliverLocked(client, frame{data: message})
+	}
+}
+
+// deliver queues an encoded event for every local connection in audience,
+// skipping topic subscribers whose user is in except.
+func (h *Hub) deliver(audience models.Audience, except []string, f frame) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	targets := make(map[*Client]bool)
+	for _, userID := range audience.UserIDs {
+		for client := range h.users[userID] {
+			targets[client] = true
+		}
+	}
+	for _, topic := range audience.Topics {
+		for client := range h.topics[topic] {
+			if !slices.Contains(except, client.UserID) {
+				targets[client] = true
 			}
-			h.mu.RUnlock()
 		}
 	}
+
+	for client := range targets {
+		h.deliverLocked(client, f)
+	}
 }
 
-func (h *Hub) BroadcastToUser(userID string, message []byte) {
+// sendTo queues a message for one client if it is still connected.
+func (h *Hub) sendTo(client *Client, message []byte) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
+	if h.clients[client] {
+		h.deliverLocked(client, frame{data: message})
+	}
+}
+
+// deliverLocked queues a message without blocking, applying the drop policy
+// when the queue is full. Callers hold at least the read lock, which keeps
+// the send channel open for the duration.
+func (h *Hub) deliverLocked(client *Client, f frame) {
+	select {
+	case client.send <- f:
+		client.stats.enqueued(len(client.send))
+		return
+	default:
+	}
+
+	switch h.policy {
+	case DropOldest:
+		// Concurrent deliveries may race for the freed slot; the loser's
+		// message is dropped instead.
+		select {
+		case <-client.send:
+			h.dropped(client)
+		default:
+		}
+		select {
+		case client.send <- f:
+			client.stats.enqueued(len(client.send))
+		default:
+			h.dropped(client)
+		}
+
+	case DropNewest:
+		h.dropped(client)
+
+	case Disconnect:
+		h.dropped(client)
+		if client.stats.evicting.CompareAndSwap(false, true) {
+			h.stats.evicted.Add(1)
+			log.Printf("Disconnecting slow client %s", client.UserID)
+			go h.Unregister(client)
+		}
+	}
+}
+
+func (h *Hub) dropped(client *Client) {
+	client.stats.dropped.Add(1)
+	h.stats.dropped.Add(1)
+}
+
+// removeLocked unindexes client and closes its send channel. Callers hold
+// the write lock.
+func (h *Hub) removeLocked(client *Client) bool {
+	if !h.clients[client] {
+		return false
+	}
+
+	delete(h.clients, client)
+	removeFromIndex(h.users, client.UserID, client)
+	for topic := range client.topics {
+		removeFromIndex(h.topics, topic, client)
+	}
+	close(client.send)
+	return true
+}
+
+func (h *Hub) shutdown() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
 	for client := range h.clients {
-		if client.UserID == userID {
-			select {
-			case client.send <- message:
-			default:
-				close(client.send)
-				delete(h.clients, client)
-			}
+		h.removeLocked(client)
+	}
+	close(h.done)
+
+	log.Println("WebSocket hub stopped")
+}
+
+// newClient builds a client whose queue matches the hub's configuration.
+// The caller sets conn for WebSocket clients; SSE clients have none.
+func (h *Hub) newClient(userID, transport string) *Client {
+	return &Client{
+		hub:       h,
+		send:      make(chan frame, h.queueSize),
+		topics:    make(map[string]bool),
+		transport: transport,
+		stats:     clientStats{connectedAt: time.Now()},
+		UserID:    userID,
+	}
+}
+
+func addToIndex(index map[string]map[*Client]bool, key string, client *Client) {
+	if index[key] == nil {
+		index[key] = make(map[*Client]bool)
+	}
+	index[key][client] = true
+}
+
+func removeFromIndex(index map[string]map[*Client]bool, key string, client *Client) {
+	if set, ok := index[key]; ok {
+		delete(set, client)
+		if len(set) == 0 {
+			delete(index, key)
 		}
 	}
 }
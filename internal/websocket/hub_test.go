@@ -0,0 +1,207 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/config"
+	"github.com/onoja123/travel-companion-backend/internal/models"
+)
+
+func TestDropPolicies(t *testing.T) {
+	tests := []struct {
+		policy  DropPolicy
+		queued  []int64
+		evicted bool
+	}{
+		{policy: DropOldest, queued: []int64{4, 5}},
+		{policy: DropNewest, queued: []int64{1, 2}},
+		{policy: Disconnect, queued: []int64{1, 2}, evicted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			hub := newTestHub(2, tt.policy)
+			client := hub.newClient("alice", "websocket")
+			hub.Register(client)
+
+			for seq := int64(1); seq <= 5; seq++ {
+				hub.deliver(models.Audience{UserIDs: []string{"alice"}}, nil, frame{seq: seq})
+			}
+
+			if tt.evicted {
+				waitFor(t, func() bool { return !hub.connected(client) })
+				if n := hub.stats.evicted.Load(); n != 1 {
+					t.Errorf("evicted %d clients, want 1", n)
+				}
+			}
+
+			var queued []int64
+			for f := range drain(client) {
+				queued = append(queued, f.seq)
+			}
+			if fmt.Sprint(queued) != fmt.Sprint(tt.queued) {
+				t.Errorf("queued %v, want %v", queued, tt.queued)
+			}
+			if n := client.stats.dropped.Load(); n != 3 {
+				t.Errorf("dropped %d, want 3", n)
+			}
+			if n := client.stats.peak.Load(); n != 2 {
+				t.Errorf("peak queue depth %d, want 2", n)
+			}
+		})
+	}
+}
+
+// TestSlowConsumersWithUnregister runs deliveries to clients that never read
+// concurrently with subscription changes and disconnects. Run with -race; a
+// send on a closed queue would panic.
+func TestSlowConsumersWithUnregister(t *testing.T) {
+	for _, policy := range []DropPolicy{DropOldest, DropNewest, Disconnect} {
+		t.Run(string(policy), func(t *testing.T) {
+			hub := newTestHub(4, policy)
+			topic := models.FlightTopic("AA100_2026-10-19")
+
+			var clients []*Client
+			for i := range 20 {
+				client := hub.newClient(fmt.Sprintf("user-%d", i%5), "websocket")
+				hub.Register(client)
+				hub.Subscribe(client, topic)
+				clients = append(clients, client)
+			}
+
+			// A few clients keep up; the rest are slow consumers
+			var readers sync.WaitGroup
+			for _, client := range clients[:5] {
+				readers.Add(1)
+				go func() {
+					defer readers.Done()
+					for range client.send {
+					}
+				}()
+			}
+
+			var wg sync.WaitGroup
+			for p := range 4 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := range 200 {
+						audience := models.Audience{UserIDs: []string{fmt.Sprintf("user-%d", i%5)}, Topics: []string{topic}}
+						hub.deliver(audience, nil, frame{seq: int64(p*1000 + i), data: []byte("{}")})
+					}
+				}()
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i, client := range clients {
+					if i%2 == 0 {
+						hub.Unsubscribe(client, topic)
+						hub.Subscribe(client, topic)
+					}
+					hub.Unregister(client)
+					hub.Unregister(client) // no-op the second time
+				}
+			}()
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 50 {
+					hub.Broadcast([]byte("{}"))
+					hub.Stats("user-0")
+				}
+			}()
+
+			wg.Wait()
+			readers.Wait()
+
+			// Evictions run asynchronously; wait for them to settle
+			waitFor(t, func() bool {
+				hub.mu.RLock()
+				defer hub.mu.RUnlock()
+				return len(hub.clients) == 0 && len(hub.users) == 0 && len(hub.topics) == 0
+			})
+			for _, client := range clients {
+				if _, ok := <-drainAll(client); ok {
+					t.Fatalf("queue of %s still open after Unregister", client.UserID)
+				}
+			}
+		})
+	}
+}
+
+func TestShutdownClosesQueues(t *testing.T) {
+	hub := newTestHub(4, DropOldest)
+	client := hub.newClient("alice", "sse")
+	hub.Register(client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		hub.Run(ctx)
+		close(done)
+	}()
+	cancel()
+	<-done
+
+	if _, ok := <-client.send; ok {
+		t.Error("queue still open after shutdown")
+	}
+	if hub.Register(hub.newClient("bob", "sse")) {
+		t.Error("Register succeeded after shutdown")
+	}
+}
+
+func newTestHub(queueSize int, policy DropPolicy) *Hub {
+	return NewHub(&config.Config{WS: config.WebSocketConfig{SendQueueSize: queueSize, DropPolicy: string(policy)}})
+}
+
+func (h *Hub) connected(client *Client) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.clients[client]
+}
+
+// drain returns the frames queued for a client that is no longer connected,
+// or those currently queued if it still is.
+func drain(client *Client) <-chan frame {
+	out := make(chan frame, cap(client.send))
+	for {
+		select {
+		case f, ok := <-client.send:
+			if !ok {
+				close(out)
+				return out
+			}
+			out <- f
+		default:
+			close(out)
+			return out
+		}
+	}
+}
+
+// drainAll discards whatever is still queued and returns the closed queue.
+func drainAll(client *Client) <-chan frame {
+	for range client.send {
+	}
+	return client.send
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
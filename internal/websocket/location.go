@@ -0,0 +1,104 @@
+package websocket
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// minLocationInterval throttles GPS fixes; faster updates are ignored.
+const minLocationInterval = time.Second
+
+// handleLocation records a GPS fix and, for the flight named in the message
+// or every trip the connection follows, replies with a fresh walk time when
+// its urgency level changes. It runs on the read pump, which owns the
+// location state.
+func (c *Client) handleLocation(msg clientMessage) {
+	if c.locations == nil {
+		c.reply(serverMessage{Type: msgError, ID: msg.ID, Error: "location updates are not supported"})
+		return
+	}
+
+	if msg.Latitude < -90 || msg.Latitude > 90 {
+		c.reply(serverMessage{Type: msgError, ID: msg.ID, Error: "invalid latitude"})
+		return
+	}
+	if msg.Longitude < -180 || msg.Longitude > 180 {
+		c.reply(serverMessage{Type: msgError, ID: msg.ID, Error: "invalid longitude"})
+		return
+	}
+
+	now := time.Now()
+	if now.Sub(c.lastLocation) < minLocationInterval {
+		return
+	}
+	c.lastLocation = now
+
+	timestamp := msg.Timestamp
+	if timestamp == "" {
+		timestamp = now.Format(time.RFC3339)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := c.locations.UpdateLocation(ctx, models.LocationUpdate{
+		UserID:    c.UserID,
+		Latitude:  msg.Latitude,
+		Longitude: msg.Longitude,
+		Timestamp: timestamp,
+	})
+	if err != nil {
+		c.reply(serverMessage{Type: msgError, ID: msg.ID, Error: "failed to update location"})
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(c.UserID)
+	if err != nil {
+		return
+	}
+
+	for _, flightID := range c.walkTimeFlights(msg.FlightID) {
+		walkTime, err := c.locations.GetWalkTime(ctx, flightID, userID)
+
+		// Report errors once per flight too, rather than on every fix.
+		state := ""
+		if err != nil {
+			state = "error:" + err.Error()
+		} else {
+			state = walkTime.UrgencyLevel
+		}
+		if c.urgency[flightID] == state {
+			continue
+		}
+		c.urgency[flightID] = state
+
+		if err != nil {
+			c.reply(serverMessage{Type: msgError, ID: msg.ID, Topic: models.TripTopic(flightID), Error: err.Error()})
+			continue
+		}
+		c.reply(serverMessage{Type: msgWalkTime, ID: msg.ID, Topic: models.TripTopic(flightID), Data: walkTime})
+	}
+}
+
+// walkTimeFlights returns the explicitly requested flight, or the tracked
+// flights of every trip topic the connection follows.
+func (c *Client) walkTimeFlights(flightID string) []string {
+	if flightID != "" {
+		return []string{flightID}
+	}
+
+	c.hub.mu.RLock()
+	defer c.hub.mu.RUnlock()
+
+	var flights []string
+	for topic := range c.topics {
+		if id, ok := strings.CutPrefix(topic, "trip:"); ok {
+			flights = append(flights, id)
+		}
+	}
+	return flights
+}
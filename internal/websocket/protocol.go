@@ -0,0 +1,147 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Client -> server message types
+const (
+	msgSubscribe   = "subscribe"
+	msgUnsubscribe = "unsubscribe"
+	msgPing        = "ping"
+	msgLocation    = "location"
+)
+
+// Server -> client control message types. Events use their own types,
+// e.g. "flight.gate_changed".
+const (
+	msgAck       = "ack"
+	msgError     = "error"
+	msgPong      = "pong"
+	msgHeartbeat = "heartbeat"
+	msgResync    = "resync" // events after last_seq were trimmed; refetch state
+	msgWalkTime  = "walk_time"
+)
+
+// TopicAuthorizer decides whether a user may subscribe to a topic.
+type TopicAuthorizer interface {
+	AuthorizeTopic(ctx context.Context, userID, topic string) error
+}
+
+// LocationTracker records GPS fixes and computes walk times to the gate.
+type LocationTracker interface {
+	UpdateLocation(ctx context.Context, update models.LocationUpdate) error
+	GetWalkTime(ctx context.Context, flightID string, userID primitive.ObjectID) (*models.WalkTimeResponse, error)
+}
+
+// clientMessage is a request from a client, e.g.
+//
+//	{"type": "subscribe", "id": "1", "flight_key": "AA100_2025-01-02"}
+//	{"type": "subscribe", "id": "2", "trip_id": "<tracked flight id>"}
+//	{"type": "unsubscribe", "id": "3", "flight_key": "AA100_2025-01-02"}
+//	{"type": "ping", "id": "4"}
+//	{"type": "location", "latitude": 40.64, "longitude": -73.78, "flight_id": "<tracked flight id>"}
+//
+// The optional id is echoed in the reply so clients can match them.
+// Location fixes are not acknowledged; the server replies with walk_time
+// when the urgency for a flight changes, or error if the fix is rejected.
+type clientMessage struct {
+	Type      string  `json:"type"`
+	ID        string  `json:"id,omitempty"`
+	FlightKey string  `json:"flight_key,omitempty"`
+	TripID    string  `json:"trip_id,omitempty"`
+	Latitude  float64 `json:"latitude,omitempty"`
+	Longitude float64 `json:"longitude,omitempty"`
+	Timestamp string  `json:"timestamp,omitempty"`
+	FlightID  string  `json:"flight_id,omitempty"`
+}
+
+// serverMessage is an acknowledgement, error, pong, heartbeat or walk time.
+type serverMessage struct {
+	Type      string      `json:"type"`
+	ID        string      `json:"id,omitempty"`
+	Topic     string      `json:"topic,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Seq       int64       `json:"seq,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+func (m clientMessage) topic() (string, error) {
+	switch {
+	case m.FlightKey != "" && m.TripID != "":
+		return "", fmt.Errorf("specify either flight_key or trip_id")
+	case m.FlightKey != "":
+		return models.FlightTopic(m.FlightKey), nil
+	case m.TripID != "":
+		return models.TripTopic(m.TripID), nil
+	}
+	return "", fmt.Errorf("flight_key or trip_id required")
+}
+
+func (c *Client) handleMessage(raw []byte) {
+	var msg clientMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		c.reply(serverMessage{Type: msgError, Error: "invalid JSON"})
+		return
+	}
+
+	switch msg.Type {
+	case msgSubscribe:
+		topic, err := msg.topic()
+		if err != nil {
+			c.reply(serverMessage{Type: msgError, ID: msg.ID, Error: err.Error()})
+			return
+		}
+
+		if c.authorizer != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			err = c.authorizer.AuthorizeTopic(ctx, c.UserID, topic)
+			cancel()
+			if err != nil {
+				c.reply(serverMessage{Type: msgError, ID: msg.ID, Topic: topic, Error: err.Error()})
+				return
+			}
+		}
+
+		if err := c.hub.Subscribe(c, topic); err != nil {
+			c.reply(serverMessage{Type: msgError, ID: msg.ID, Topic: topic, Error: err.Error()})
+			return
+		}
+		c.reply(serverMessage{Type: msgAck, ID: msg.ID, Topic: topic})
+
+	case msgUnsubscribe:
+		topic, err := msg.topic()
+		if err != nil {
+			c.reply(serverMessage{Type: msgError, ID: msg.ID, Error: err.Error()})
+			return
+		}
+
+		c.hub.Unsubscribe(c, topic)
+		c.reply(serverMessage{Type: msgAck, ID: msg.ID, Topic: topic})
+
+	case msgPing:
+		c.reply(serverMessage{Type: msgPong, ID: msg.ID})
+
+	case msgLocation:
+		c.handleLocation(msg)
+
+	default:
+		c.reply(serverMessage{Type: msgError, ID: msg.ID, Error: fmt.Sprintf("unknown message type %q", msg.Type)})
+	}
+}
+
+func (c *Client) reply(msg serverMessage) {
+	msg.Timestamp = time.Now()
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	c.hub.sendTo(c, data)
+}
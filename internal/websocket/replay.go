@@ -0,0 +1,185 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/onoja123/travel-companion-backend/internal/database"
+	"github.com/onoja123/travel-companion-backend/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// replayMaxLen bounds each user's stream; older events are trimmed.
+	replayMaxLen = 500
+	// replayTTL expires the stream of a user who stops receiving events.
+	replayTTL = 24 * time.Hour
+	// appendBatchSize bounds how many users one append script handles, so a
+	// flight with many followers does not block Redis in a single call.
+	appendBatchSize = 200
+)
+
+// appendScript assigns each user, given as counter and stream key pairs,
+// the next sequence number and appends the event under stream ID "<seq>-0"
+// in one step, so concurrent replicas cannot interleave the counter and the
+// stream. The stored event carries its sequence number only in the entry ID.
+// The counter has no TTL so sequence numbers never go backwards for a user.
+var appendScript = redis.NewScript(`
+local seqs = {}
+for i = 1, #KEYS, 2 do
+	local seq = redis.call('INCR', KEYS[i])
+	redis.call('XADD', KEYS[i + 1], 'MAXLEN', '~', ARGV[2], seq .. '-0', 'event', ARGV[1])
+	redis.call('EXPIRE', KEYS[i + 1], ARGV[3])
+	seqs[#seqs + 1] = seq
+end
+return seqs
+`)
+
+// EventLog is the per-user event history used to resume a connection: every
+// event addressed to a user gets a monotonic sequence number and is kept in
+// a bounded Redis Stream so a reconnecting client can fetch what it missed.
+type EventLog struct {
+	redis *database.RedisClient
+}
+
+func NewEventLog(redisClient *database.RedisClient) *EventLog {
+	return &EventLog{redis: redisClient}
+}
+
+// Append sequences event for each of userIDs, records it and returns the
+// sequence numbers, in the same order, with the event encoded for each user.
+// Users are appended in batches of one round trip each; if a batch fails,
+// those recorded before it are still returned along with the error.
+func (l *EventLog) Append(ctx context.Context, userIDs []string, event models.Event) ([]int64, [][]byte, error) {
+	event.Seq = 0
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seqs := make([]int64, 0, len(userIDs))
+	var appendErr error
+	for start := 0; start < len(userIDs); start += appendBatchSize {
+		batch := userIDs[start:min(start+appendBatchSize, len(userIDs))]
+		keys := make([]string, 0, 2*len(batch))
+		for _, userID := range batch {
+			keys = append(keys, seqKey(userID), streamKey(userID))
+		}
+
+		assigned, err := appendScript.Run(ctx, l.redis.Client, keys,
+			string(data), replayMaxLen, int(replayTTL.Seconds()),
+		).Int64Slice()
+		if err != nil {
+			appendErr = fmt.Errorf("failed to append event: %w", err)
+			break
+		}
+		seqs = append(seqs, assigned...)
+	}
+
+	messages := make([][]byte, len(seqs))
+	for i, seq := range seqs {
+		event.Seq = seq
+		if messages[i], err = json.Marshal(event); err != nil {
+			return nil, nil, err
+		}
+	}
+	return seqs, messages, appendErr
+}
+
+// Since returns the events after lastSeq in order. If the stream has been
+// trimmed past lastSeq, gap is true and the caller should tell the client
+// to refetch its state.
+func (l *EventLog) Since(ctx context.Context, userID string, lastSeq int64) (events []frame, gap bool, err error) {
+	entries, err := l.redis.Client.XRange(ctx, streamKey(userID), fmt.Sprintf("%d-1", lastSeq), "+").Result()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read event stream: %w", err)
+	}
+
+	for _, entry := range entries {
+		seq, err := entrySeq(entry.ID)
+		if err != nil {
+			continue
+		}
+		raw, _ := entry.Values["event"].(string)
+
+		var event models.Event
+		if err := json.Unmarshal([]byte(raw), &event); err != nil {
+			continue
+		}
+		event.Seq = seq
+
+		data, err := json.Marshal(event)
+		if err != nil {
+			continue
+		}
+		events = append(events, frame{seq: seq, data: data})
+	}
+
+	if len(events) > 0 {
+		gap = events[0].seq > lastSeq+1
+	} else {
+		// Nothing retained after lastSeq: a gap only if the user has been
+		// sent later events that have since expired.
+		current, err := l.redis.Client.Get(ctx, seqKey(userID)).Int64()
+		if err != nil && err != redis.Nil {
+			return nil, false, fmt.Errorf("failed to read sequence: %w", err)
+		}
+		gap = current > lastSeq
+	}
+
+	return events, gap, nil
+}
+
+func entrySeq(id string) (int64, error) {
+	ms, _, _ := strings.Cut(id, "-")
+	return strconv.ParseInt(ms, 10, 64)
+}
+
+func seqKey(userID string) string {
+	return fmt.Sprintf("ws:seq:%s", userID)
+}
+
+func streamKey(userID string) string {
+	return fmt.Sprintf("ws:stream:%s", userID)
+}
+
+// replay writes the events a resuming client missed straight to its
+// connection, before the write pump starts, and records the sequence
+// number it has caught up to. If the log no longer covers lastSeq the client
+// is told to resync, and still receives what remains.
+func (h *Handler) replay(client *Client, lastSeq int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events, gap, err := h.events.Since(ctx, client.UserID, lastSeq)
+	if err != nil {
+		return err
+	}
+	client.replayed = lastSeq
+
+	if gap {
+		msg := serverMessage{Type: msgResync, Timestamp: time.Now()}
+		if len(events) > 0 {
+			msg.Seq = events[0].seq
+		}
+		client.conn.SetWriteDeadline(time.Now().Add(writeWait))
+		if err := client.conn.WriteJSON(msg); err != nil {
+			return err
+		}
+	}
+
+	for _, event := range events {
+		client.conn.SetWriteDeadline(time.Now().Add(writeWait))
+		if err := client.conn.WriteMessage(websocket.TextMessage, event.data); err != nil {
+			return err
+		}
+		client.replayed = event.seq
+	}
+
+	return nil
+}
@@ -0,0 +1,17 @@
+package websocket
+
+import "testing"
+
+func TestLiveEventsOutOfOrderAreNotDropped(t *testing.T) {
+	client := &Client{replayed: 3}
+
+	// Replay covered 1-3; 5 is published before 4 finishes delivering
+	for _, f := range []struct {
+		seq  int64
+		skip bool
+	}{{seq: 2, skip: true}, {seq: 3, skip: true}, {seq: 5}, {seq: 4}, {seq: 0}} {
+		if got := client.alreadyReplayed(frame{seq: f.seq}); got != f.skip {
+			t.Errorf("alreadyReplayed(seq %d) = %v, want %v", f.seq, got, f.skip)
+		}
+	}
+}
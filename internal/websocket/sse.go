@@ -0,0 +1,161 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onoja123/travel-companion-backend/internal/models"
+	"github.com/onoja123/travel-companion-backend/internal/utils"
+)
+
+// sseKeepalivePeriod keeps idle streams open through proxies that close
+// silent connections.
+const sseKeepalivePeriod = 15 * time.Second
+
+// ServeSSE godoc
+// @Summary Stream realtime events over Server-Sent Events
+// @Description The /ws event feed for clients behind proxies that break WebSockets. Authenticate with an Authorization: Bearer header or a ticket from POST /api/ws/ticket (?ticket=..., for EventSource). Sequenced events carry their seq as the event ID, so reconnecting with Last-Event-ID replays missed events. Topics are fixed per stream via flight_key and trip_id.
+// @Tags realtime
+// @Produce text/event-stream
+// @Param ticket query string false "Single-use connection ticket"
+// @Param flight_key query []string false "Flight keys to follow, e.g. AA100_2025-01-02"
+// @Param trip_id query []string false "Tracked flight IDs to follow"
+// @Param last_event_id query int false "Fallback for the Last-Event-ID header"
+// @Router /api/events/stream [get]
+func (h *Handler) ServeSSE(c *gin.Context) {
+	userID, _, err := h.authenticate(c)
+	if err != nil {
+		utils.ErrorResponse(c, 401, err.Error())
+		return
+	}
+
+	resume := false
+	var lastSeq int64
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+	if lastID != "" {
+		lastSeq, err = strconv.ParseInt(lastID, 10, 64)
+		if err != nil || lastSeq < 0 {
+			utils.ErrorResponse(c, 400, "Invalid Last-Event-ID")
+			return
+		}
+		resume = true
+	}
+
+	var topics []string
+	for _, key := range c.QueryArray("flight_key") {
+		topics = append(topics, models.FlightTopic(key))
+	}
+	for _, id := range c.QueryArray("trip_id") {
+		topics = append(topics, models.TripTopic(id))
+	}
+	if len(topics) > maxSubscriptions {
+		utils.ErrorResponse(c, 400, fmt.Sprintf("At most %d topics per stream", maxSubscriptions))
+		return
+	}
+
+	if h.authorizer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		for _, topic := range topics {
+			if err := h.authorizer.AuthorizeTopic(ctx, userID, topic); err != nil {
+				utils.ErrorResponse(c, 403, fmt.Sprintf("%s: %v", topic, err))
+				return
+			}
+		}
+	}
+
+	// An SSE client has no socket of its own; the hub only needs its queue.
+	client := h.hub.newClient(userID, "sse")
+
+	if !h.hub.Register(client) {
+		utils.ErrorResponse(c, 503, "Server shutting down")
+		return
+	}
+	defer h.hub.Unregister(client)
+
+	for _, topic := range topics {
+		if err := h.hub.Subscribe(client, topic); err != nil {
+			utils.ErrorResponse(c, 500, err.Error())
+			return
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(200)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", 5000)
+	c.Writer.Flush()
+
+	if resume {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		events, gap, err := h.events.Since(ctx, userID, lastSeq)
+		cancel()
+		if err != nil {
+			log.Printf("SSE replay for %s failed: %v", userID, err)
+			return
+		}
+
+		if gap {
+			msg := serverMessage{Type: msgResync, Timestamp: time.Now()}
+			if len(events) > 0 {
+				msg.Seq = events[0].seq
+			}
+			data, _ := json.Marshal(msg)
+			writeSSE(c, frame{data: data})
+		}
+		client.replayed = lastSeq
+		for _, event := range events {
+			writeSSE(c, event)
+			client.replayed = event.seq
+		}
+		c.Writer.Flush()
+	}
+
+	keepalive := time.NewTicker(sseKeepalivePeriod)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+
+		case f, ok := <-client.send:
+			if !ok {
+				return
+			}
+
+			if client.alreadyReplayed(f) {
+				continue
+			}
+
+			writeSSE(c, f)
+			c.Writer.Flush()
+			client.stats.sent.Add(1)
+
+		case <-keepalive.C:
+			fmt.Fprint(c.Writer, ": keepalive\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeSSE writes one event. Only sequenced events get an ID, so the
+// browser's Last-Event-ID always names a replayable event.
+func writeSSE(c *gin.Context, f frame) {
+	if f.seq > 0 {
+		fmt.Fprintf(c.Writer, "id: %d\n", f.seq)
+	}
+	fmt.Fprintf(c.Writer, "data: %s\n\n", f.data)
+}
@@ -0,0 +1,113 @@
+package websocket
+
+import (
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/onoja123/travel-companion-backend/internal/utils"
+)
+
+// hubStats are cumulative counters across all connections.
+type hubStats struct {
+	connected atomic.Int64
+	dropped   atomic.Int64
+	evicted   atomic.Int64
+}
+
+// clientStats track one connection's send queue. Counters are atomic
+// because deliveries run concurrently under the hub's read lock.
+type clientStats struct {
+	connectedAt time.Time
+	sent        atomic.Int64
+	dropped     atomic.Int64
+	peak        atomic.Int64 // deepest the send queue has been
+	evicting    atomic.Bool
+}
+
+func (s *clientStats) enqueued(depth int) {
+	for {
+		peak := s.peak.Load()
+		if int64(depth) <= peak || s.peak.CompareAndSwap(peak, int64(depth)) {
+			return
+		}
+	}
+}
+
+// HubStats is a snapshot of the hub and of one user's connections.
+type HubStats struct {
+	Connections     int               `json:"connections"`
+	Users           int               `json:"users"`
+	Topics          int               `json:"topics"`
+	DropPolicy      DropPolicy        `json:"drop_policy"`
+	TotalConnected  int64             `json:"total_connected"`
+	TotalDropped    int64             `json:"total_dropped"`
+	TotalEvicted    int64             `json:"total_evicted"`
+	UserConnections []ConnectionStats `json:"user_connections"`
+}
+
+// ConnectionStats describes one connection's send queue.
+type ConnectionStats struct {
+	Transport     string    `json:"transport"`
+	ConnectedAt   time.Time `json:"connected_at"`
+	Subscriptions int       `json:"subscriptions"`
+	QueueLength   int       `json:"queue_length"`
+	QueueCapacity int       `json:"queue_capacity"`
+	QueuePeak     int       `json:"queue_peak"`
+	Sent          int64     `json:"sent"`
+	Dropped       int64     `json:"dropped"`
+}
+
+// Stats reports hub totals and the queues of userID's connections.
+func (h *Hub) Stats(userID string) HubStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	stats := HubStats{
+		Connections:     len(h.clients),
+		Users:           len(h.users),
+		Topics:          len(h.topics),
+		DropPolicy:      h.policy,
+		TotalConnected:  h.stats.connected.Load(),
+		TotalDropped:    h.stats.dropped.Load(),
+		TotalEvicted:    h.stats.evicted.Load(),
+		UserConnections: []ConnectionStats{},
+	}
+
+	for client := range h.users[userID] {
+		stats.UserConnections = append(stats.UserConnections, ConnectionStats{
+			Transport:     client.transport,
+			ConnectedAt:   client.stats.connectedAt,
+			Subscriptions: len(client.topics),
+			QueueLength:   len(client.send),
+			QueueCapacity: cap(client.send),
+			QueuePeak:     int(client.stats.peak.Load()),
+			Sent:          client.stats.sent.Load(),
+			Dropped:       client.stats.dropped.Load(),
+		})
+	}
+	slices.SortFunc(stats.UserConnections, func(a, b ConnectionStats) int {
+		return a.ConnectedAt.Compare(b.ConnectedAt)
+	})
+
+	return stats
+}
+
+// GetStats godoc
+// @Summary Realtime connection metrics
+// @Description Hub-wide totals plus send-queue metrics for each of the caller's live WebSocket and SSE connections
+// @Tags realtime
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Router /api/ws/stats [get]
+func (h *Handler) GetStats(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.ErrorResponse(c, 401, "Unauthorized")
+		return
+	}
+
+	utils.SuccessResponse(c, 200, "Realtime stats retrieved", h.hub.Stats(userID))
+}
@@ -0,0 +1,66 @@
+package websocket
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/onoja123/travel-companion-backend/internal/database"
+	"github.com/redis/go-redis/v9"
+)
+
+// TicketStore issues short-lived, single-use connection tickets. Browsers
+// cannot set headers on a WebSocket handshake, so a client exchanges its JWT
+// for a ticket over HTTPS and passes that in the /ws query string instead of
+// exposing the long-lived token in URLs and logs.
+type TicketStore struct {
+	redis *database.RedisClient
+	ttl   time.Duration
+}
+
+// defaultTicketTTL is used when no valid lifetime is configured; without
+// one Redis would keep unused tickets forever.
+const defaultTicketTTL = 30 * time.Second
+
+func NewTicketStore(redisClient *database.RedisClient, ttl time.Duration) *TicketStore {
+	if ttl <= 0 {
+		ttl = defaultTicketTTL
+	}
+	return &TicketStore{
+		redis: redisClient,
+		ttl:   ttl,
+	}
+}
+
+func (s *TicketStore) Issue(ctx context.Context, userID string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate ticket: %w", err)
+	}
+	ticket := hex.EncodeToString(buf)
+
+	if err := s.redis.Client.Set(ctx, ticketKey(ticket), userID, s.ttl).Err(); err != nil {
+		return "", fmt.Errorf("failed to store ticket: %w", err)
+	}
+
+	return ticket, nil
+}
+
+// Redeem returns the ticket's user and deletes it so it cannot be replayed.
+func (s *TicketStore) Redeem(ctx context.Context, ticket string) (string, error) {
+	userID, err := s.redis.Client.GetDel(ctx, ticketKey(ticket)).Result()
+	if err == redis.Nil {
+		return "", fmt.Errorf("invalid or expired ticket")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return userID, nil
+}
+
+func ticketKey(ticket string) string {
+	return fmt.Sprintf("ws:ticket:%s", ticket)
+}
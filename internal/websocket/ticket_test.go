@@ -0,0 +1,25 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/onoja123/travel-companion-backend/internal/database"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestTicketsExpireWithoutConfiguredTTL(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	store := NewTicketStore(&database.RedisClient{Client: client}, 0)
+	ticket, err := store.Issue(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if ttl := mr.TTL(ticketKey(ticket)); ttl != defaultTicketTTL {
+		t.Errorf("ticket TTL %v, want %v", ttl, defaultTicketTTL)
+	}
+}
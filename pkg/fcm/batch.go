@@ -0,0 +1,108 @@
+package fcm
+
+import (
+	"context"
+	"fmt"
+
+	"firebase.google.com/go/v4/messaging"
+)
+
+// MaxBatchSize is the most messages FCM accepts in a single SendEach call.
+const MaxBatchSize = 500
+
+// Message is a single push addressed to a registration token.
+type Message struct {
+	Token string
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// BatchResult summarises a batch send. A batch can partially fail: failures
+// are reported per token rather than as a single error.
+type BatchResult struct {
+	SuccessCount  int
+	FailureCount  int
+	InvalidTokens []string         // tokens FCM will never accept again
+	Failed        map[string]error // other per-token failures, usually transient
+}
+
+func newBatchResult() *BatchResult {
+	return &BatchResult{Failed: make(map[string]error)}
+}
+
+// merge folds other into r. It is not safe for concurrent use.
+func (r *BatchResult) merge(other *BatchResult) {
+	r.SuccessCount += other.SuccessCount
+	r.FailureCount += other.FailureCount
+	r.InvalidTokens = append(r.InvalidTokens, other.InvalidTokens...)
+	for token, err := range other.Failed {
+		r.Failed[token] = err
+	}
+}
+
+// SendBatch sends messages with SendEach, MaxBatchSize at a time. Use a
+// Dispatcher to send large fan-outs concurrently.
+func (s *FCMService) SendBatch(ctx context.Context, messages []Message) (*BatchResult, error) {
+	result := newBatchResult()
+
+	for start := 0; start < len(messages); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(messages))
+
+		chunk, err := s.sendChunk(ctx, messages[start:end])
+		if err != nil {
+			return result, err
+		}
+		result.merge(chunk)
+	}
+
+	return result, nil
+}
+
+// SendMulticast sends the same notification to every token.
+func (s *FCMService) SendMulticast(ctx context.Context, tokens []string, title, body string, data map[string]string) (*BatchResult, error) {
+	messages := make([]Message, len(tokens))
+	for i, token := range tokens {
+		messages[i] = Message{Token: token, Title: title, Body: body, Data: data}
+	}
+
+	return s.SendBatch(ctx, messages)
+}
+
+func (s *FCMService) sendChunk(ctx context.Context, messages []Message) (*BatchResult, error) {
+	if len(messages) > MaxBatchSize {
+		return nil, fmt.Errorf("batch of %d exceeds FCM limit of %d", len(messages), MaxBatchSize)
+	}
+
+	result := newBatchResult()
+	if len(messages) == 0 {
+		return result, nil
+	}
+
+	batch := make([]*messaging.Message, len(messages))
+	for i, m := range messages {
+		batch[i] = newMessage(m)
+	}
+
+	response, err := s.client.SendEach(ctx, batch)
+	if err != nil {
+		// The whole request failed (auth, network); nothing was delivered.
+		return nil, fmt.Errorf("failed to send FCM batch: %w", err)
+	}
+
+	for i, r := range response.Responses {
+		if r.Success {
+			result.SuccessCount++
+			continue
+		}
+
+		result.FailureCount++
+		if IsInvalidToken(r.Error) {
+			result.InvalidTokens = append(result.InvalidTokens, messages[i].Token)
+		} else {
+			result.Failed[messages[i].Token] = r.Error
+		}
+	}
+
+	return result, nil
+}
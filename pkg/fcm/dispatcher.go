@@ -0,0 +1,78 @@
+package fcm
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// Dispatcher fans a large set of messages out over FCM, sending up to
+// concurrency SendEach batches at a time.
+type Dispatcher struct {
+	service *FCMService
+	sem     chan struct{}
+}
+
+func NewDispatcher(service *FCMService, concurrency int) *Dispatcher {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &Dispatcher{
+		service: service,
+		sem:     make(chan struct{}, concurrency),
+	}
+}
+
+// Dispatch sends every message and merges the per-batch results. A batch
+// that fails outright is logged and counted as failed for all its tokens;
+// the remaining batches are still sent.
+func (d *Dispatcher) Dispatch(ctx context.Context, messages []Message) *BatchResult {
+	result := newBatchResult()
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+
+	for start := 0; start < len(messages); start += MaxBatchSize {
+		end := min(start+MaxBatchSize, len(messages))
+		chunk := messages[start:end]
+
+		select {
+		case d.sem <- struct{}{}:
+		case <-ctx.Done():
+			mu.Lock()
+			markFailed(result, chunk, ctx.Err())
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-d.sem }()
+
+			chunkResult, err := d.service.sendChunk(ctx, chunk)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				log.Printf("Error sending FCM batch of %d: %v", len(chunk), err)
+				markFailed(result, chunk, err)
+				return
+			}
+			result.merge(chunkResult)
+		}()
+	}
+
+	wg.Wait()
+	return result
+}
+
+func markFailed(result *BatchResult, messages []Message, err error) {
+	for _, m := range messages {
+		result.FailureCount++
+		result.Failed[m.Token] = err
+	}
+}
@@ -0,0 +1,89 @@
+package fcm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"firebase.google.com/go/v4/messaging"
+)
+
+// FakeBackend is an in-memory Backend for tests and local development. It
+// records every message it is asked to send and rejects tokens marked
+// invalid with ErrInvalidToken.
+type FakeBackend struct {
+	mu      sync.Mutex
+	sent    []*messaging.Message
+	invalid map[string]bool
+	seq     int
+}
+
+func NewFakeBackend() *FakeBackend {
+	return &FakeBackend{
+		invalid: make(map[string]bool),
+	}
+}
+
+// Invalidate makes every later send to the given tokens fail.
+func (f *FakeBackend) Invalidate(tokens ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, token := range tokens {
+		f.invalid[token] = true
+	}
+}
+
+// Sent returns a copy of every successfully sent message, in order.
+func (f *FakeBackend) Sent() []*messaging.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]*messaging.Message(nil), f.sent...)
+}
+
+func (f *FakeBackend) Send(ctx context.Context, message *messaging.Message) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sendLocked(message)
+}
+
+func (f *FakeBackend) SendEach(ctx context.Context, messages []*messaging.Message) (*messaging.BatchResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(messages) > MaxBatchSize {
+		return nil, fmt.Errorf("fake fcm: batch of %d exceeds %d", len(messages), MaxBatchSize)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	response := &messaging.BatchResponse{}
+	for _, message := range messages {
+		id, err := f.sendLocked(message)
+		if err != nil {
+			response.FailureCount++
+			response.Responses = append(response.Responses, &messaging.SendResponse{Error: err})
+			continue
+		}
+		response.SuccessCount++
+		response.Responses = append(response.Responses, &messaging.SendResponse{Success: true, MessageID: id})
+	}
+
+	return response, nil
+}
+
+func (f *FakeBackend) sendLocked(message *messaging.Message) (string, error) {
+	if message.Token != "" && f.invalid[message.Token] {
+		return "", fmt.Errorf("%w: %s", ErrInvalidToken, message.Token)
+	}
+
+	f.seq++
+	f.sent = append(f.sent, message)
+	return fmt.Sprintf("projects/fake/messages/%d", f.seq), nil
+}
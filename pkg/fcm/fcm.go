@@ -2,6 +2,7 @@ package fcm
 
 import (
 	"context"
+	"errors"
 	"log"
 
 	firebase "firebase.google.com/go/v4"
@@ -9,8 +10,19 @@ import (
 	"google.golang.org/api/option"
 )
 
+// ErrInvalidToken is returned by backends that are not Firebase (see
+// FakeBackend) for a token that should be treated as unregistered.
+var ErrInvalidToken = errors.New("fcm: registration token is not registered")
+
+// Backend is the subset of *messaging.Client used by FCMService, so tests
+// and local development can run against FakeBackend instead of Firebase.
+type Backend interface {
+	Send(ctx context.Context, message *messaging.Message) (string, error)
+	SendEach(ctx context.Context, messages []*messaging.Message) (*messaging.BatchResponse, error)
+}
+
 type FCMService struct {
-	client *messaging.Client
+	client Backend
 }
 
 func NewFCMService(credentialsPath string) (*FCMService, error) {
@@ -30,14 +42,42 @@ func NewFCMService(credentialsPath string) (*FCMService, error) {
 	return &FCMService{client: client}, nil
 }
 
+// NewFCMServiceWithBackend wraps an arbitrary backend, typically FakeBackend.
+func NewFCMServiceWithBackend(backend Backend) *FCMService {
+	return &FCMService{client: backend}
+}
+
 func (s *FCMService) SendNotification(token, title, body string, data map[string]string) error {
+	message := newMessage(Message{Token: token, Title: title, Body: body, Data: data})
+
+	response, err := s.client.Send(context.Background(), message)
+	if err != nil {
+		log.Printf("Error sending FCM notification: %v", err)
+		return err
+	}
+
+	log.Printf("Successfully sent FCM message: %s", response)
+	return nil
+}
+
+// IsInvalidToken reports whether err means the registration token will never
+// work again, so the caller should stop sending to it. Invalid-argument
+// errors are not included: a malformed message causes them too, and would
+// otherwise prune every device it was sent to.
+func IsInvalidToken(err error) bool {
+	return errors.Is(err, ErrInvalidToken) ||
+		messaging.IsUnregistered(err) ||
+		messaging.IsSenderIDMismatch(err)
+}
+
+func newMessage(m Message) *messaging.Message {
 	message := &messaging.Message{
 		Notification: &messaging.Notification{
-			Title: title,
-			Body:  body,
+			Title: m.Title,
+			Body:  m.Body,
 		},
-		Data:  data,
-		Token: token,
+		Data:  m.Data,
+		Token: m.Token,
 		Android: &messaging.AndroidConfig{
 			Priority: "high",
 		},
@@ -48,12 +88,5 @@ func (s *FCMService) SendNotification(token, title, body string, data map[string
 		},
 	}
 
-	response, err := s.client.Send(context.Background(), message)
-	if err != nil {
-		log.Printf("Error sending FCM notification: %v", err)
-		return err
-	}
-
-	log.Printf("Successfully sent FCM message: %s", response)
-	return nil
+	return message
 }
@@ -0,0 +1,144 @@
+package fcm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/v4/messaging"
+)
+
+func TestSendBatchPartialFailure(t *testing.T) {
+	backend := NewFakeBackend()
+	backend.Invalidate("stale")
+	service := NewFCMServiceWithBackend(backend)
+
+	result, err := service.SendMulticast(context.Background(), []string{"a", "stale", "b"}, "Gate change", "Now B32", nil)
+	if err != nil {
+		t.Fatalf("SendMulticast: %v", err)
+	}
+
+	if result.SuccessCount != 2 || result.FailureCount != 1 {
+		t.Errorf("got %d sent, %d failed; want 2 sent, 1 failed", result.SuccessCount, result.FailureCount)
+	}
+	if len(result.InvalidTokens) != 1 || result.InvalidTokens[0] != "stale" {
+		t.Errorf("InvalidTokens = %v, want [stale]", result.InvalidTokens)
+	}
+	if len(result.Failed) != 0 {
+		t.Errorf("Failed = %v, want none", result.Failed)
+	}
+
+	sent := backend.Sent()
+	if len(sent) != 2 || sent[0].Token != "a" || sent[1].Token != "b" {
+		t.Errorf("sent to %v, want a and b", tokensOf(sent))
+	}
+}
+
+func TestSendBatchSplitsIntoChunks(t *testing.T) {
+	backend := &countingBackend{FakeBackend: NewFakeBackend()}
+	service := NewFCMServiceWithBackend(backend)
+
+	result, err := service.SendBatch(context.Background(), messagesFor(2*MaxBatchSize+1))
+	if err != nil {
+		t.Fatalf("SendBatch: %v", err)
+	}
+
+	if result.SuccessCount != 2*MaxBatchSize+1 {
+		t.Errorf("SuccessCount = %d, want %d", result.SuccessCount, 2*MaxBatchSize+1)
+	}
+	if calls := backend.calls.Load(); calls != 3 {
+		t.Errorf("SendEach called %d times, want 3", calls)
+	}
+}
+
+func TestIsInvalidToken(t *testing.T) {
+	if !IsInvalidToken(fmt.Errorf("send: %w", ErrInvalidToken)) {
+		t.Error("wrapped ErrInvalidToken should be invalid")
+	}
+	if IsInvalidToken(errors.New("malformed payload")) {
+		t.Error("other errors should not be invalid")
+	}
+}
+
+func TestDispatcherConcurrencyLimit(t *testing.T) {
+	const concurrency = 3
+
+	backend := &countingBackend{FakeBackend: NewFakeBackend(), delay: 20 * time.Millisecond}
+	backend.Invalidate("token-7")
+	dispatcher := NewDispatcher(NewFCMServiceWithBackend(backend), concurrency)
+
+	total := 10 * MaxBatchSize
+	result := dispatcher.Dispatch(context.Background(), messagesFor(total))
+
+	if peak := backend.peak.Load(); peak > concurrency {
+		t.Errorf("%d batches in flight, want at most %d", peak, concurrency)
+	} else if peak < 2 {
+		t.Errorf("%d batches in flight, want batches sent concurrently", peak)
+	}
+	if calls := backend.calls.Load(); calls != 10 {
+		t.Errorf("SendEach called %d times, want 10", calls)
+	}
+	if result.SuccessCount != total-1 || result.FailureCount != 1 {
+		t.Errorf("got %d sent, %d failed; want %d sent, 1 failed", result.SuccessCount, result.FailureCount, total-1)
+	}
+	if len(result.InvalidTokens) != 1 || result.InvalidTokens[0] != "token-7" {
+		t.Errorf("InvalidTokens = %v, want [token-7]", result.InvalidTokens)
+	}
+}
+
+func TestDispatcherCanceled(t *testing.T) {
+	dispatcher := NewDispatcher(NewFCMServiceWithBackend(NewFakeBackend()), 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := dispatcher.Dispatch(ctx, messagesFor(MaxBatchSize+1))
+	if result.SuccessCount != 0 || result.FailureCount != MaxBatchSize+1 {
+		t.Errorf("got %d sent, %d failed; want all %d failed", result.SuccessCount, result.FailureCount, MaxBatchSize+1)
+	}
+}
+
+// countingBackend wraps FakeBackend to count SendEach calls and track how
+// many run at once.
+type countingBackend struct {
+	*FakeBackend
+	delay    time.Duration
+	calls    atomic.Int32
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (b *countingBackend) SendEach(ctx context.Context, messages []*messaging.Message) (*messaging.BatchResponse, error) {
+	b.calls.Add(1)
+	n := b.inFlight.Add(1)
+	defer b.inFlight.Add(-1)
+
+	for {
+		peak := b.peak.Load()
+		if n <= peak || b.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	time.Sleep(b.delay)
+	return b.FakeBackend.SendEach(ctx, messages)
+}
+
+func messagesFor(n int) []Message {
+	messages := make([]Message, n)
+	for i := range messages {
+		messages[i] = Message{Token: fmt.Sprintf("token-%d", i), Title: "Boarding", Body: "Now boarding"}
+	}
+	return messages
+}
+
+func tokensOf(messages []*messaging.Message) []string {
+	tokens := make([]string, len(messages))
+	for i, m := range messages {
+		tokens[i] = m.Token
+	}
+	return tokens
+}